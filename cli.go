@@ -0,0 +1,528 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Коды выхода CLI, по ним скрипты различают причины ошибок
+const (
+	exitOK              = 0
+	exitError           = 1
+	exitUsage           = 2
+	exitSessionNotFound = 3
+	exitSlotMissing     = 4
+	exitEncoding        = 5
+	exitEditorRunning   = 6
+	exitInvalidPreset   = 7
+)
+
+// cliCommand - подкоманда CLI; если первый аргумент - ее имя, GUI не запускается
+type cliCommand struct {
+	usage string // Идентификатор строки использования в каталоге сообщений
+	run   func(args []string) error
+}
+
+var cliCommands = map[string]cliCommand{
+	"apply":    {usage: "usage.apply", run: runApplyCommand},
+	"batch":    {usage: "usage.batch", run: runBatchCommand},
+	"blend":    {usage: "usage.blend", run: runBlendCommand},
+	"diff":     {usage: "usage.diff", run: runDiffCommand},
+	"fetch":    {usage: "usage.fetch", run: runFetchCommand},
+	"generate": {usage: "usage.generate", run: runGenerateCommand},
+	"publish":  {usage: "usage.publish", run: runPublishCommand},
+	"validate": {usage: "usage.validate", run: runValidateCommand},
+	"vary":     {usage: "usage.vary", run: runVaryCommand},
+}
+
+// cliCommandNames возвращает имена подкоманд по алфавиту
+func cliCommandNames() []string {
+	names := make([]string, 0, len(cliCommands))
+	for name := range cliCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// usageError - неверные аргументы командной строки
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// runCLI выполняет подкоманду и возвращает код выхода
+func runCLI(name string, args []string) int {
+	command := cliCommands[name]
+	err := command.run(args)
+	if err == nil {
+		return exitOK
+	}
+
+	fmt.Fprintln(os.Stderr, modules.T("cli.error", "Error", err))
+	var usage usageError
+	if errors.As(err, &usage) {
+		fmt.Fprintln(os.Stderr, modules.T("cli.usage", "Usage", modules.T(command.usage)))
+	}
+	return exitCode(err)
+}
+
+// exitCode сопоставляет типизированные ошибки modules с кодами выхода
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, modules.ErrSessionNotFound):
+		return exitSessionNotFound
+	case errors.Is(err, modules.ErrSlotMissing):
+		return exitSlotMissing
+	case errors.Is(err, modules.ErrEncoding):
+		return exitEncoding
+	case errors.Is(err, modules.ErrEditorRunning):
+		return exitEditorRunning
+	case errors.Is(err, modules.ErrInvalidValue), errors.Is(err, modules.ErrInheritance):
+		return exitInvalidPreset
+	default:
+		return exitError
+	}
+}
+
+// newCommandFlags создает набор флагов подкоманды с общими флагами --profile и --config
+func newCommandFlags(name string) (*flag.FlagSet, *string, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	profileName := flags.String("profile", "", modules.T("flag.profile"))
+	configFile := flags.String("config", "", modules.T("flag.config"))
+	return flags, profileName, configFile
+}
+
+// variableFlags добавляет повторяемый флаг --var имя=значение; значения собираются в map
+func variableFlags(flags *flag.FlagSet) map[string]string {
+	variables := make(map[string]string)
+	flags.Func("var", modules.T("flag.var"), func(text string) error {
+		name, value, err := modules.ParseVariable(text)
+		if err != nil {
+			return err
+		}
+		variables[name] = value
+		return nil
+	})
+	return variables
+}
+
+// setCLIVariables задает профилю значения из флагов --var и спрашивает в консоли
+// значения остальных переменных шаблонов пресетов
+func setCLIVariables(profile *modules.Profile, variables map[string]string, presetNames ...string) error {
+	for name, value := range variables {
+		profile.SetVariable(name, value)
+	}
+	for _, presetName := range presetNames {
+		if err := modules.AskPresetVariables(profile, presetName, modules.ConsolePrompter{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadCLIProfile настраивает конфигурацию и журнал и возвращает профиль; недостающие пути спрашиваются в консоли
+func loadCLIProfile(profileName, configFile string) (*modules.Profile, error) {
+	if err := initCLIConfig(configFile); err != nil {
+		return nil, err
+	}
+	return modules.GetPaths(profileName, modules.ConsolePrompter{})
+}
+
+// initCLIConfig задает путь к config.json и настраивает по нему журнал и язык
+func initCLIConfig(configFile string) error {
+	modules.SetConfigPath(configFile)
+	config, _ := modules.LoadConfig()
+	if err := modules.InitLogging(config); err != nil {
+		return err
+	}
+	if config != nil && config.Language != "" {
+		return modules.InitLocale(config.Language)
+	}
+	return nil
+}
+
+// runApplyCommand применяет пресет к миру профиля
+func runApplyCommand(args []string) error {
+	flags, profileName, configFile := newCommandFlags("apply")
+	extraSlots := flags.String("extra-slots", "", modules.T("flag.extraSlots"))
+	variables := variableFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return usageError{message: err.Error()}
+	}
+	if flags.NArg() != 1 {
+		return usageError{message: modules.T("cli.onePreset")}
+	}
+	if err := modules.ValidateExtraSlots(*extraSlots); err != nil {
+		return usageError{message: err.Error()}
+	}
+	presetName := flags.Arg(0)
+
+	profile, err := loadCLIProfile(*profileName, *configFile)
+	if err != nil {
+		return err
+	}
+	if *extraSlots != "" {
+		profile.Slots.Extra = *extraSlots
+	}
+
+	// Если пресета еще нет локально, загружаем пресеты из источников профиля
+	if !modules.PresetExists(presetName) {
+		if err := modules.FetchPresets(profile); err != nil {
+			return err
+		}
+	}
+	// Родителей пресета (extends) тоже может не быть локально
+	if err := modules.FetchPresetParents(profile, presetName); err != nil {
+		return err
+	}
+	if err := setCLIVariables(profile, variables, presetName); err != nil {
+		return err
+	}
+
+	result, err := modules.ApplyPreset(profile, presetName)
+	for _, line := range result.Summary() {
+		fmt.Println(line)
+	}
+	return err
+}
+
+// runBatchCommand применяет задание JSON/YAML к нескольким мирам за один проход
+func runBatchCommand(args []string) error {
+	flags, profileName, configFile := newCommandFlags("batch")
+	variables := variableFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return usageError{message: err.Error()}
+	}
+	if flags.NArg() != 1 {
+		return usageError{message: modules.T("cli.oneJob")}
+	}
+
+	job, err := modules.LoadBatchJob(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	// Профиль из флага важнее профиля из задания
+	name := *profileName
+	if name == "" {
+		name = job.Profile
+	}
+	profile, err := loadCLIProfile(name, *configFile)
+	if err != nil {
+		return err
+	}
+
+	// Если каких-то пресетов еще нет локально, загружаем пресеты из источников профиля
+	presets := job.Presets()
+	for _, preset := range presets {
+		if !modules.PresetExists(preset) {
+			if err := modules.FetchPresets(profile); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	for _, preset := range presets {
+		if err := modules.FetchPresetParents(profile, preset); err != nil {
+			return err
+		}
+	}
+	if err := setCLIVariables(profile, variables, presets...); err != nil {
+		return err
+	}
+
+	result, err := modules.ApplyBatch(profile, job)
+	for _, line := range result.Summary() {
+		fmt.Println(line)
+	}
+	return err
+}
+
+// runFetchCommand загружает пресеты из источников профиля. Пресеты с локальными правками
+// перезаписываются только с --overwrite. Пути REDkit не нужны.
+func runFetchCommand(args []string) error {
+	flags, profileName, configFile := newCommandFlags("fetch")
+	overwrite := flags.Bool("overwrite", false, modules.T("flag.overwrite"))
+	if err := flags.Parse(args); err != nil {
+		return usageError{message: err.Error()}
+	}
+	if flags.NArg() != 0 {
+		return usageError{message: modules.T("cli.noArgs")}
+	}
+	if err := initCLIConfig(*configFile); err != nil {
+		return err
+	}
+	profile, err := modules.SourcesProfile(*profileName)
+	if err != nil {
+		return err
+	}
+
+	result, err := modules.UpdatePresets(profile, *overwrite)
+	if err != nil {
+		return err
+	}
+	fmt.Println(modules.T("cli.fetched", "Updated", len(result.Updated)))
+	if len(result.Kept) > 0 {
+		fmt.Println(modules.T("cli.fetchKept", "Count", len(result.Kept), "Presets", strings.Join(result.Kept, ", ")))
+	}
+	return nil
+}
+
+// runValidateCommand проверяет файлы или папки пресетов и печатает замечания "файл:строка: ...".
+// С --json печатает массив замечаний в JSON. Код выхода 7, если есть ошибки (предупреждения не считаются).
+// Профиль и пути REDkit не нужны, поэтому команду можно запускать в pre-commit репозитория пресетов.
+func runValidateCommand(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	jsonOutput := flags.Bool("json", false, modules.T("flag.json"))
+	textures := flags.Int("textures", 0, modules.T("flag.textures"))
+	if err := flags.Parse(args); err != nil {
+		return usageError{message: err.Error()}
+	}
+	if flags.NArg() == 0 {
+		return usageError{message: modules.T("cli.validatePaths")}
+	}
+
+	issues, err := modules.LintPresets(flags.Args(), modules.LintOptions{TextureCount: *textures})
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		if issues == nil {
+			issues = []modules.LintIssue{}
+		}
+		data, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+	}
+
+	if modules.HasLintErrors(issues) {
+		return fmt.Errorf("%w: %s", modules.ErrInvalidValue, modules.T("cli.validateFailed", "Count", len(issues)))
+	}
+	return nil
+}
+
+// runDiffCommand сравнивает два пресета (имена или пути к файлам) по слотам
+func runDiffCommand(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	all := flags.Bool("all", false, modules.T("flag.diffAll"))
+	if err := flags.Parse(args); err != nil {
+		return usageError{message: err.Error()}
+	}
+	if flags.NArg() != 2 {
+		return usageError{message: modules.T("cli.twoPresets")}
+	}
+
+	comparison, err := modules.ComparePresets(flags.Arg(0), flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	for _, line := range comparison.Lines(*all) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// runGenerateCommand строит пресет слотов материалов по параметрам и сохраняет его
+// в папку пресетов или в файл из --out
+func runGenerateCommand(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	layers := flags.Int("layers", 1, modules.T("flag.layers"))
+	bands := flags.Int("bands", 1, modules.T("flag.bands"))
+	minHeight := flags.Float64("min", 0, modules.T("flag.minHeight"))
+	maxHeight := flags.Float64("max", 0, modules.T("flag.maxHeight"))
+	slopes := flags.String("slopes", "", modules.T("flag.slopes"))
+	slopeAction := flags.Int("slope-action", 0, modules.T("flag.slopeAction"))
+	textures := flags.String("textures", "", modules.T("flag.textureList"))
+	description := flags.String("description", "", modules.T("flag.description"))
+	author := flags.String("author", "", modules.T("flag.author"))
+	out := flags.String("out", "", modules.T("flag.out"))
+	force := flags.Bool("force", false, modules.T("flag.force"))
+	if err := flags.Parse(args); err != nil {
+		return usageError{message: err.Error()}
+	}
+	if flags.NArg() != 1 {
+		return usageError{message: modules.T("cli.onePreset")}
+	}
+
+	thresholds, err := modules.ParseIntList(*slopes)
+	if err != nil {
+		return usageError{message: err.Error()}
+	}
+	textureList, err := modules.ParseGeneratorTextures(*textures)
+	if err != nil {
+		return usageError{message: err.Error()}
+	}
+
+	preset, err := modules.GeneratePreset(modules.GeneratorParams{
+		Name:            flags.Arg(0),
+		Description:     *description,
+		Author:          *author,
+		Layers:          *layers,
+		Bands:           *bands,
+		MinHeight:       *minHeight,
+		MaxHeight:       *maxHeight,
+		SlopeThresholds: thresholds,
+		SlopeAction:     *slopeAction,
+		Textures:        textureList,
+	})
+	if err != nil {
+		return err
+	}
+	path, err := modules.SavePreset(preset, *out, *force)
+	if err != nil {
+		return err
+	}
+	for _, line := range preset.Summary() {
+		fmt.Println(line)
+	}
+	fmt.Println(modules.T("cli.generated", "Path", path, "Slots", len(preset.Slots)))
+	return nil
+}
+
+// runBlendCommand строит промежуточный пресет между двумя пресетами, сохраняет его
+// и с --apply сразу применяет к миру профиля
+func runBlendCommand(args []string) error {
+	flags, profileName, configFile := newCommandFlags("blend")
+	factor := flags.Float64("t", 0.5, modules.T("flag.blendFactor"))
+	out := flags.String("out", "", modules.T("flag.out"))
+	force := flags.Bool("force", false, modules.T("flag.force"))
+	apply := flags.Bool("apply", false, modules.T("flag.blendApply"))
+	if err := flags.Parse(args); err != nil {
+		return usageError{message: err.Error()}
+	}
+	if flags.NArg() != 3 {
+		return usageError{message: modules.T("cli.blendArgs")}
+	}
+
+	preset, err := modules.BlendPresets(flags.Arg(0), flags.Arg(1), *factor)
+	if err != nil {
+		return err
+	}
+	preset.Metadata.Name = flags.Arg(2)
+	path, err := modules.SavePreset(preset, *out, *force)
+	if err != nil {
+		return err
+	}
+	fmt.Println(modules.T("cli.blended", "Path", path, "Slots", len(preset.Slots)))
+	if !*apply {
+		return nil
+	}
+
+	profile, err := loadCLIProfile(*profileName, *configFile)
+	if err != nil {
+		return err
+	}
+	result, err := modules.ApplyPresetFile(profile, preset)
+	for _, line := range result.Summary() {
+		fmt.Println(line)
+	}
+	return err
+}
+
+// runVaryCommand создает один или несколько вариантов пресета со случайными отклонениями.
+// Варианты с --count получают имена <имя>_1, <имя>_2, ... и зерна seed, seed+1, ...
+func runVaryCommand(args []string) error {
+	flags := flag.NewFlagSet("vary", flag.ContinueOnError)
+	seed := flags.Int64("seed", -1, modules.T("flag.seed"))
+	count := flags.Int("count", 1, modules.T("flag.count"))
+	jitterText := flags.String("jitter", "", modules.T("flag.jitter"))
+	out := flags.String("out", "", modules.T("flag.out"))
+	force := flags.Bool("force", false, modules.T("flag.force"))
+	if err := flags.Parse(args); err != nil {
+		return usageError{message: err.Error()}
+	}
+	if flags.NArg() != 2 {
+		return usageError{message: modules.T("cli.varyArgs")}
+	}
+	if *count < 1 || *count > 1 && *out != "" {
+		return usageError{message: modules.T("cli.varyCount")}
+	}
+	jitter, err := modules.ParseJitter(*jitterText)
+	if err != nil {
+		return usageError{message: err.Error()}
+	}
+	if *seed < 0 {
+		*seed = modules.NewSeed()
+	}
+
+	for i := 0; i < *count; i++ {
+		variant, err := modules.VaryPreset(flags.Arg(0), *seed+int64(i), jitter)
+		if err != nil {
+			return err
+		}
+		variant.Metadata.Name = flags.Arg(1)
+		if *count > 1 {
+			variant.Metadata.Name = fmt.Sprintf("%s_%d", flags.Arg(1), i+1)
+		}
+		path, err := modules.SavePreset(variant, *out, *force)
+		if err != nil {
+			return err
+		}
+		fmt.Println(modules.T("cli.varied", "Path", path, "Seed", *variant.Metadata.Seed))
+	}
+	return nil
+}
+
+// runPublishCommand открывает pull request с локальным пресетом в репозитории пресетов.
+// С --dry-run только печатает заголовок, описание и файлы. Пути REDkit не нужны.
+func runPublishCommand(args []string) error {
+	flags := flag.NewFlagSet("publish", flag.ContinueOnError)
+	configFile := flags.String("config", "", modules.T("flag.config"))
+	title := flags.String("title", "", modules.T("flag.publishTitle"))
+	dryRun := flags.Bool("dry-run", false, modules.T("flag.dryRun"))
+	if err := flags.Parse(args); err != nil {
+		return usageError{message: err.Error()}
+	}
+	if flags.NArg() != 1 {
+		return usageError{message: modules.T("cli.onePreset")}
+	}
+	if err := initCLIConfig(*configFile); err != nil {
+		return err
+	}
+
+	publication, err := modules.PreparePublication(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *title != "" {
+		publication.Title = *title
+	}
+	if *dryRun {
+		fmt.Println(publication.Title)
+		fmt.Println()
+		fmt.Print(publication.Body)
+		return nil
+	}
+
+	settings, err := modules.LoadGitHubSettings()
+	if err != nil {
+		return err
+	}
+	result, err := modules.PublishPreset(settings, publication)
+	if err != nil {
+		return err
+	}
+	fmt.Println(modules.T("cli.published", "URL", result.URL, "Repository", result.Repository, "Branch", result.Branch))
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newBatchButton создает кнопку пакетного применения: задание JSON/YAML выбирается в диалоге
+// и применяется к файлу сессий текущего профиля. Задание с другим profile не применяется:
+// сначала нужно переключиться на его профиль.
+func newBatchButton(window fyne.Window, profile *modules.Profile) *widget.Button {
+	return widget.NewButton(modules.T("gui.batch"), func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+
+			job, err := modules.LoadBatchJob(path)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			// Задание для другого профиля записало бы миры не в тот файл сессий
+			if job.Profile != "" && job.Profile != profile.Name {
+				dialog.ShowError(fmt.Errorf("%s", modules.T("error.batchProfile", "Job", job.Profile, "Current", profile.Name)), window)
+				return
+			}
+			// Запрос переменных шаблонов ждет ответа, поэтому выполняется не в UI-потоке
+			go func() {
+				for _, preset := range job.Presets() {
+					if err := modules.AskPresetVariables(profile, preset, fynePrompter{window: window}); err != nil {
+						dialog.ShowError(err, window)
+						return
+					}
+				}
+				result, err := modules.ApplyBatch(profile, job)
+				showBatchResult(result, err, window)
+			}()
+		}, window)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".json", ".yaml", ".yml"}))
+		open.Show()
+	})
+}
+
+// showBatchResult показывает общий отчет пакетного применения
+func showBatchResult(result modules.BatchResult, err error, window fyne.Window) {
+	var slotErr *modules.SlotMissingError
+	switch {
+	case err == nil:
+		dialog.ShowInformation(modules.T("gui.batchTitle"), strings.Join(result.Summary(), "\n"), window)
+	case errors.As(err, &slotErr):
+		message := strings.Join(result.Summary(), "\n") + "\n" + modules.T("gui.slotsMissingHint")
+		dialog.ShowInformation(modules.T("gui.partialTitle"), message, window)
+	default:
+		showApplyResult(modules.ApplyResult{}, err, window)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newBlendButton создает кнопку смешивания двух пресетов; onSaved вызывается после сохранения результата
+func newBlendButton(window fyne.Window, profile *modules.Profile, presets func() []string, onSaved func()) *widget.Button {
+	return widget.NewButton(modules.T("gui.blend"), func() {
+		showBlendDialog(window, profile, presets(), onSaved)
+	})
+}
+
+// showBlendDialog показывает выбор двух пресетов и ползунок t с предпросмотром смешанных значений.
+// Результат можно сохранить как пресет или сразу применить.
+func showBlendDialog(window fyne.Window, profile *modules.Profile, presets []string, onSaved func()) {
+	selectA := widget.NewSelect(presets, nil)
+	selectB := widget.NewSelect(presets, nil)
+	factorLabel := widget.NewLabel("")
+	slider := widget.NewSlider(0, 1)
+	slider.Step = 0.05
+	slider.Value = 0.5
+
+	slots := widget.NewAccordion()
+	scroll := container.NewScroll(slots)
+	scroll.SetMinSize(fyne.NewSize(560, 320))
+
+	var blended *modules.PresetFile
+	var saveButton, applyButton *widget.Button
+	update := func() {
+		factorLabel.SetText(modules.T("gui.blendFactor", "Value", strconv.FormatFloat(slider.Value, 'f', 2, 64)))
+		blended = nil
+		slots.Items = nil
+		if selectA.Selected != "" && selectB.Selected != "" {
+			preset, err := modules.BlendPresets(selectA.Selected, selectB.Selected, slider.Value)
+			if err != nil {
+				slots.Append(widget.NewAccordionItem(modules.T("gui.blendError"), widget.NewLabel(err.Error())))
+			} else {
+				blended = preset
+				for _, slot := range preset.Slots {
+					grid := container.NewGridWithColumns(2)
+					for _, key := range slot.Values.Keys {
+						grid.Add(widget.NewLabel(key))
+						grid.Add(widget.NewLabel(slot.Values.Values[key]))
+					}
+					slots.Append(widget.NewAccordionItem(slot.Path, grid))
+				}
+			}
+		}
+		slots.Refresh()
+		if blended == nil {
+			saveButton.Disable()
+			applyButton.Disable()
+		} else {
+			saveButton.Enable()
+			applyButton.Enable()
+		}
+	}
+
+	saveButton = widget.NewButton(modules.T("gui.save"), func() {
+		preset := *blended
+		name := widget.NewEntry()
+		name.SetText(selectA.Selected + "_" + selectB.Selected)
+		name.Validator = modules.ValidatePresetName
+		dialog.ShowForm(modules.T("gui.blendSaveTitle"), modules.T("gui.save"), modules.T("gui.cancel"), []*widget.FormItem{
+			widget.NewFormItem(modules.T("gui.name"), name),
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+			preset.Metadata.Name = name.Text
+			savePreset(&preset, window, onSaved)
+		}, window)
+	})
+	applyButton = widget.NewButton(modules.T("gui.apply"), func() {
+		preset := *blended
+		preset.Metadata.Name = modules.T("gui.blendName", "A", selectA.Selected, "B", selectB.Selected, "Value", strconv.FormatFloat(slider.Value, 'f', 2, 64))
+		preview, err := modules.PreviewPresetFile(profile, &preset)
+		if err != nil {
+			showApplyResult(modules.ApplyResult{}, err, window)
+			return
+		}
+		showPreview(preview, window)
+	})
+
+	selectA.OnChanged = func(string) { update() }
+	selectB.OnChanged = func(string) { update() }
+	slider.OnChangeEnded = func(float64) { update() }
+	slider.OnChanged = func(value float64) {
+		factorLabel.SetText(modules.T("gui.blendFactor", "Value", strconv.FormatFloat(value, 'f', 2, 64)))
+	}
+	update()
+
+	form := widget.NewForm(
+		widget.NewFormItem(modules.T("gui.compareA"), selectA),
+		widget.NewFormItem(modules.T("gui.compareB"), selectB),
+	)
+	top := container.NewVBox(form, container.NewBorder(nil, nil, factorLabel, nil, slider))
+	content := container.NewBorder(top, container.NewHBox(saveButton, applyButton), nil, nil, scroll)
+	dialog.ShowCustom(modules.T("gui.blendTitle"), modules.T("gui.close"), content, window)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"BiomeManager/modules"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newCompareButton создает кнопку сравнения двух пресетов; список имен запрашивается при нажатии
+func newCompareButton(window fyne.Window, presets func() []string) *widget.Button {
+	return widget.NewButton(modules.T("gui.compare"), func() {
+		selectA := widget.NewSelect(presets(), nil)
+		selectB := widget.NewSelect(presets(), nil)
+		items := []*widget.FormItem{
+			widget.NewFormItem(modules.T("gui.compareA"), selectA),
+			widget.NewFormItem(modules.T("gui.compareB"), selectB),
+		}
+		dialog.ShowForm(modules.T("gui.compareTitle"), modules.T("gui.compare"), modules.T("gui.cancel"), items, func(confirmed bool) {
+			if !confirmed || selectA.Selected == "" || selectB.Selected == "" {
+				return
+			}
+			comparison, err := modules.ComparePresets(selectA.Selected, selectB.Selected)
+			if err != nil {
+				showApplyResult(modules.ApplyResult{}, err, window)
+				return
+			}
+			showComparison(comparison, window)
+		}, window)
+	})
+}
+
+// showComparison показывает пресеты рядом по слотам: у каждого слота однострочный итог,
+// в раскрытом слоте - ключи с двумя значениями, различающиеся выделены
+func showComparison(comparison *modules.PresetComparison, window fyne.Window) {
+	accordion := widget.NewAccordion()
+	for _, slot := range comparison.Slots {
+		grid := container.NewGridWithColumns(3,
+			widget.NewLabelWithStyle(modules.T("gui.compareKey"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(comparison.A, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(comparison.B, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		)
+		for _, key := range slot.Keys {
+			valueA := widget.NewLabel(modules.DisplayValue(key.A, key.InA))
+			valueB := widget.NewLabel(modules.DisplayValue(key.B, key.InB))
+			if key.Differs {
+				valueA.Importance = widget.WarningImportance
+				valueB.Importance = widget.WarningImportance
+			}
+			grid.Add(widget.NewLabel(key.Key))
+			grid.Add(valueA)
+			grid.Add(valueB)
+		}
+		accordion.Append(widget.NewAccordionItem(slot.Summary(comparison.A, comparison.B), grid))
+	}
+
+	scroll := container.NewScroll(accordion)
+	scroll.SetMinSize(fyne.NewSize(640, 400))
+	dialog.ShowCustom(modules.T("gui.compareResultTitle", "A", comparison.A, "B", comparison.B), modules.T("gui.close"), scroll, window)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// showApplyResult показывает итог применения пресета: успех, предупреждение о пропущенных слотах или ошибку
+func showApplyResult(result modules.ApplyResult, err error, window fyne.Window) {
+	var slotErr *modules.SlotMissingError
+	var encodingErr *modules.EncodingError
+	var invalidErr *modules.InvalidPresetError
+
+	switch {
+	case err == nil:
+		dialog.ShowInformation(modules.T("gui.appliedTitle"), strings.Join(result.Summary(), "\n"), window)
+	case errors.As(err, &slotErr):
+		message := modules.T("gui.slotsMissing", "Slots", strings.Join(slotErr.Slots, ", "), "World", slotErr.World)
+		if result.Changed() {
+			message += "\n" + strings.Join(result.Summary(), "\n")
+		}
+		message += "\n" + modules.T("gui.slotsMissingHint")
+		dialog.ShowInformation(modules.T("gui.partialTitle"), message, window)
+	case errors.Is(err, modules.ErrEditorRunning):
+		dialog.ShowInformation(modules.T("gui.editorRunningTitle"), modules.T("gui.editorRunningMessage"), window)
+	case errors.Is(err, modules.ErrSessionNotFound):
+		dialog.ShowError(fmt.Errorf("%v\n%s", err, modules.T("gui.checkRedkitPath")), window)
+	case errors.As(err, &encodingErr):
+		dialog.ShowError(fmt.Errorf("%s", modules.T("gui.encodingError", "Path", encodingErr.Path, "Reason", encodingErr.Reason)), window)
+	case errors.As(err, &invalidErr):
+		lines := make([]string, len(invalidErr.Problems))
+		for i, problem := range invalidErr.Problems {
+			lines[i] = problem.String()
+		}
+		dialog.ShowInformation(modules.T("gui.invalidPresetTitle"), strings.Join(lines, "\n"), window)
+	default:
+		dialog.ShowError(err, window)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"errors"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newGenerateButton создает кнопку мастера генерации пресета; onSaved вызывается после сохранения
+func newGenerateButton(window fyne.Window, profile *modules.Profile, onSaved func()) *widget.Button {
+	return widget.NewButton(modules.T("gui.generate"), func() {
+		params := modules.GeneratorParams{Layers: 2, Bands: 3, MaxHeight: 300, TextureCount: profile.TextureCount}
+		showGenerateNameStep(&params, window, onSaved)
+	})
+}
+
+// Мастер генерации идет по шагам: имя и описание, полосы высот и слои, пороги крутизны и текстуры,
+// затем предпросмотр слотов с сохранением
+
+// showGenerateNameStep - шаг 1: имя, описание и автор пресета
+func showGenerateNameStep(params *modules.GeneratorParams, window fyne.Window, onSaved func()) {
+	name := widget.NewEntry()
+	name.SetText(params.Name)
+	name.Validator = modules.ValidatePresetName
+	description := widget.NewEntry()
+	description.SetText(params.Description)
+	author := widget.NewEntry()
+	author.SetText(params.Author)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(modules.T("gui.name"), name),
+		widget.NewFormItem(modules.T("gui.generateDescription"), description),
+		widget.NewFormItem(modules.T("gui.generateAuthor"), author),
+	}
+	dialog.ShowForm(modules.T("gui.generateTitle", "Step", 1), modules.T("gui.next"), modules.T("gui.cancel"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		params.Name = strings.TrimSpace(name.Text)
+		params.Description = description.Text
+		params.Author = author.Text
+		showGenerateBandsStep(params, window, onSaved)
+	}, window)
+}
+
+// showGenerateBandsStep - шаг 2: диапазон высот, число полос и слоев
+func showGenerateBandsStep(params *modules.GeneratorParams, window fyne.Window, onSaved func()) {
+	layers := intEntry(params.Layers)
+	bands := intEntry(params.Bands)
+	minHeight := floatEntry(params.MinHeight)
+	maxHeight := floatEntry(params.MaxHeight)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(modules.T("gui.generateBands"), bands),
+		widget.NewFormItem(modules.T("gui.generateMinHeight"), minHeight),
+		widget.NewFormItem(modules.T("gui.generateMaxHeight"), maxHeight),
+		widget.NewFormItem(modules.T("gui.generateLayers"), layers),
+	}
+	items[3].HintText = modules.T("gui.generateLayersHint")
+	dialog.ShowForm(modules.T("gui.generateTitle", "Step", 2), modules.T("gui.next"), modules.T("gui.cancel"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		params.Layers, _ = strconv.Atoi(layers.Text)
+		params.Bands, _ = strconv.Atoi(bands.Text)
+		params.MinHeight, _ = strconv.ParseFloat(minHeight.Text, 64)
+		params.MaxHeight, _ = strconv.ParseFloat(maxHeight.Text, 64)
+		showGenerateSlopesStep(params, window, onSaved)
+	}, window)
+}
+
+// showGenerateSlopesStep - шаг 3: порог крутизны для каждого слоя, кроме первого, и список текстур
+func showGenerateSlopesStep(params *modules.GeneratorParams, window fyne.Window, onSaved func()) {
+	var items []*widget.FormItem
+	thresholds := make([]*widget.Entry, max(params.Layers-1, 0))
+	for i := range thresholds {
+		value := 0
+		if i < len(params.SlopeThresholds) {
+			value = params.SlopeThresholds[i]
+		}
+		thresholds[i] = intEntry(value)
+		items = append(items, widget.NewFormItem(modules.T("gui.generateSlope", "Layer", i+2), thresholds[i]))
+	}
+	action := intEntry(params.SlopeAction)
+	if len(thresholds) > 0 {
+		items = append(items, widget.NewFormItem(modules.T("gui.generateSlopeAction"), action))
+	}
+
+	var texts []string
+	for _, texture := range params.Textures {
+		texts = append(texts, strconv.Itoa(texture.Horizontal)+":"+strconv.Itoa(texture.Vertical))
+	}
+	textures := widget.NewEntry()
+	textures.SetText(strings.Join(texts, ","))
+	textures.Validator = func(text string) error {
+		list, err := modules.ParseGeneratorTextures(text)
+		if err == nil && len(list) == 0 {
+			err = errors.New(modules.T("generator.noTextures"))
+		}
+		return err
+	}
+	texturesItem := widget.NewFormItem(modules.T("gui.generateTextures"), textures)
+	texturesItem.HintText = modules.T("gui.generateTexturesHint")
+	items = append(items, texturesItem)
+
+	dialog.ShowForm(modules.T("gui.generateTitle", "Step", 3), modules.T("gui.next"), modules.T("gui.cancel"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		params.SlopeThresholds = params.SlopeThresholds[:0]
+		for _, entry := range thresholds {
+			value, _ := strconv.Atoi(entry.Text)
+			params.SlopeThresholds = append(params.SlopeThresholds, value)
+		}
+		params.SlopeAction, _ = strconv.Atoi(action.Text)
+		params.Textures, _ = modules.ParseGeneratorTextures(textures.Text)
+		showGeneratePreview(params, window, onSaved)
+	}, window)
+}
+
+// showGeneratePreview - последний шаг: слоты будущего пресета и сохранение в папку пресетов.
+// Ошибка параметров возвращает к шагу 2, чтобы их можно было исправить.
+func showGeneratePreview(params *modules.GeneratorParams, window fyne.Window, onSaved func()) {
+	preset, err := modules.GeneratePreset(*params)
+	if err != nil {
+		errorDialog := dialog.NewError(err, window)
+		errorDialog.SetOnClosed(func() { showGenerateBandsStep(params, window, onSaved) })
+		errorDialog.Show()
+		return
+	}
+
+	text := widget.NewLabel(strings.Join(preset.Summary(), "\n"))
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+	scroll := container.NewScroll(text)
+	scroll.SetMinSize(fyne.NewSize(560, 280))
+
+	dialog.ShowCustomConfirm(modules.T("gui.generatePreviewTitle", "Name", params.Name), modules.T("gui.save"), modules.T("gui.cancel"), scroll, func(ok bool) {
+		if !ok {
+			return
+		}
+		savePreset(preset, window, onSaved)
+	}, window)
+}
+
+// savePreset сохраняет пресет в папку пресетов биомов; если файл есть, спрашивает о замене
+func savePreset(preset *modules.PresetFile, window fyne.Window, onSaved func()) {
+	save := func(overwrite bool) {
+		path, err := modules.SavePreset(preset, "", overwrite)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		dialog.ShowInformation(modules.T("gui.presetSavedTitle"), modules.T("gui.presetSaved", "Path", path), window)
+		if onSaved != nil {
+			onSaved()
+		}
+	}
+
+	if modules.FileExists(modules.BiomeFamily.NativePath(preset.Metadata.Name)) {
+		dialog.ShowConfirm(modules.T("gui.presetExistsTitle"), modules.T("gui.presetExists", "Name", preset.Metadata.Name), func(ok bool) {
+			if ok {
+				save(true)
+			}
+		}, window)
+		return
+	}
+	save(false)
+}
+
+// intEntry создает поле для целого числа не меньше нуля
+func intEntry(value int) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.Itoa(value))
+	entry.Validator = func(text string) error {
+		if number, err := strconv.Atoi(text); err != nil || number < 0 {
+			return errors.New(modules.T("generator.badNumber", "Value", text))
+		}
+		return nil
+	}
+	return entry
+}
+
+// floatEntry создает поле для дробного числа
+func floatEntry(value float64) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.FormatFloat(value, 'f', -1, 64))
+	entry.Validator = func(text string) error {
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			return errors.New(modules.T("generator.badNumber", "Value", text))
+		}
+		return nil
+	}
+	return entry
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newHistoryPanel собирает сворачиваемую панель истории применений с кнопками отмены и повтора
+// и назначает окну сочетания Ctrl+Z и Ctrl+Y. afterChange вызывается после каждого изменения истории,
+// чтобы обновить зависящие от применений части окна.
+func newHistoryPanel(window fyne.Window, afterChange func()) fyne.CanvasObject {
+	entries := modules.ApplyHistory.Entries()
+
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			entry := entries[id]
+			text := modules.T("gui.historyEntry", "Time", entry.Time.Format("15:04:05"), "Description", entry.Description)
+			if entry.Undone {
+				text = modules.T("gui.historyUndone", "Entry", text)
+			}
+			item.(*widget.Label).SetText(text)
+		},
+	)
+
+	undoButton := widget.NewButton(modules.T("gui.undo"), func() { undoLastStep(window) })
+	redoButton := widget.NewButton(modules.T("gui.redo"), func() { redoLastStep(window) })
+	updateButtons := func() {
+		if modules.ApplyHistory.CanUndo() {
+			undoButton.Enable()
+		} else {
+			undoButton.Disable()
+		}
+		if modules.ApplyHistory.CanRedo() {
+			redoButton.Enable()
+		} else {
+			redoButton.Disable()
+		}
+	}
+	updateButtons()
+
+	modules.ApplyHistory.OnChange(func() {
+		entries = modules.ApplyHistory.Entries()
+		list.Refresh()
+		updateButtons()
+		if afterChange != nil {
+			afterChange()
+		}
+	})
+
+	canvasWindow := window.Canvas()
+	canvasWindow.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { undoLastStep(window) })
+	canvasWindow.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyY, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { redoLastStep(window) })
+
+	// Прозрачный прямоугольник задает минимальную высоту развернутой панели
+	sizer := canvas.NewRectangle(color.Transparent)
+	sizer.SetMinSize(fyne.NewSize(0, 120))
+
+	buttons := container.NewHBox(undoButton, redoButton)
+	historyItem := widget.NewAccordionItem(modules.T("gui.history"), container.NewBorder(nil, buttons, nil, nil, container.NewStack(sizer, list)))
+	return widget.NewAccordion(historyItem)
+}
+
+// undoLastStep отменяет последнее применение и сообщает о ключах, измененных после него
+func undoLastStep(window fyne.Window) {
+	patch, skipped, err := modules.ApplyHistory.Undo()
+	showHistoryStep(patch, skipped, err, "gui.undoneTitle", window)
+}
+
+// redoLastStep повторяет последнее отмененное применение
+func redoLastStep(window fyne.Window) {
+	patch, skipped, err := modules.ApplyHistory.Redo()
+	showHistoryStep(patch, skipped, err, "gui.redoneTitle", window)
+}
+
+// showHistoryStep показывает ошибку шага или список пропущенных ключей; успешный шаг виден в списке истории
+func showHistoryStep(patch *modules.Patch, skipped []string, err error, titleID string, window fyne.Window) {
+	switch {
+	case err != nil:
+		showApplyResult(modules.ApplyResult{}, err, window)
+	case patch != nil && len(skipped) > 0:
+		message := modules.T("gui.historySkipped", "Description", patch.Description) + "\n" + strings.Join(skipped, "\n")
+		dialog.ShowInformation(modules.T(titleID), message, window)
+	}
+}
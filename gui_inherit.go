@@ -0,0 +1,68 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newInheritanceButton создает кнопку просмотра наследования выбранного пресета
+func newInheritanceButton(window fyne.Window, selected func() string) *widget.Button {
+	return widget.NewButton(modules.T("gui.inheritance"), func() {
+		presetName := selected()
+		if presetName == "" {
+			dialog.ShowInformation(modules.T("gui.noPresetTitle"), modules.T("gui.noPresetMessage"), window)
+			return
+		}
+		inheritance, err := modules.InheritanceOf(presetName)
+		if err != nil {
+			showApplyResult(modules.ApplyResult{}, err, window)
+			return
+		}
+		showInheritance(presetName, inheritance, window)
+	})
+}
+
+// showInheritance показывает цепочку родителей и значения пресета по секциям: у каждого значения
+// указано, задано ли оно в самом пресете, взято у родителя или переопределяет значение родителя
+func showInheritance(presetName string, inheritance *modules.PresetInheritance, window fyne.Window) {
+	accordion := widget.NewAccordion()
+	for _, section := range inheritance.Sections {
+		grid := container.NewGridWithColumns(3,
+			widget.NewLabelWithStyle(modules.T("gui.compareKey"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(modules.T("gui.inheritValue"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(modules.T("gui.inheritOrigin"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		)
+		overridden := 0
+		for _, value := range section.Values {
+			valueLabel := widget.NewLabel(value.Value)
+			var origin *widget.Label
+			switch {
+			case value.Overridden:
+				overridden++
+				valueLabel.Importance = widget.WarningImportance
+				origin = widget.NewLabel(modules.T("gui.inheritOverridden", "Preset", value.Origin, "Value", value.ParentValue))
+			case value.Origin == presetName:
+				origin = widget.NewLabel(modules.T("gui.inheritOwn"))
+			default:
+				valueLabel.Importance = widget.LowImportance
+				origin = widget.NewLabel(modules.T("gui.inheritFrom", "Preset", value.Origin))
+			}
+			grid.Add(widget.NewLabel(value.Key))
+			grid.Add(valueLabel)
+			grid.Add(origin)
+		}
+		title := modules.T("gui.inheritSection", "Path", section.Path, "Count", overridden)
+		accordion.Append(widget.NewAccordionItem(title, grid))
+	}
+
+	chain := widget.NewLabel(modules.T("gui.inheritChain", "Chain", strings.Join(inheritance.Chain, " → ")))
+	scroll := container.NewScroll(accordion)
+	scroll.SetMinSize(fyne.NewSize(640, 400))
+	content := container.NewBorder(chain, nil, nil, nil, scroll)
+	dialog.ShowCustom(modules.T("gui.inheritTitle", "Preset", presetName), modules.T("gui.close"), content, window)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"image/color"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newLogPanel собирает сворачиваемую панель с последними строками журнала
+func newLogPanel() fyne.CanvasObject {
+	// Строки обновляются из горутины журнала, а читаются списком в потоке интерфейса
+	var mu sync.Mutex
+	lines := modules.LogLines.Lines()
+	line := func(id int) string {
+		mu.Lock()
+		defer mu.Unlock()
+		if id < 0 || id >= len(lines) {
+			return ""
+		}
+		return lines[id]
+	}
+
+	list := widget.NewList(
+		func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(lines)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			return label
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(line(id))
+		},
+	)
+
+	modules.LogLines.OnChange(func() {
+		updated := modules.LogLines.Lines()
+		mu.Lock()
+		lines = updated
+		mu.Unlock()
+		list.Refresh()
+		list.ScrollToBottom()
+	})
+
+	// Прозрачный прямоугольник задает минимальную высоту развернутой панели
+	sizer := canvas.NewRectangle(color.Transparent)
+	sizer.SetMinSize(fyne.NewSize(0, 160))
+
+	logItem := widget.NewAccordionItem(modules.T("gui.log", "Path", modules.LogFilePath()), container.NewStack(sizer, list))
+	return widget.NewAccordion(logItem)
+}
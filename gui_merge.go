@@ -0,0 +1,104 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"errors"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// previewAndApply показывает изменения, которые сделает пресет, и применяет его после подтверждения.
+// Состояние файла на момент предпросмотра служит базой для слияния с правками, сделанными позже.
+// Значения переменных шаблона, которых нет в профиле, сначала спрашиваются в форме.
+func previewAndApply(profile *modules.Profile, presetName string, window fyne.Window) {
+	// Форма переменных ждет ответа, поэтому выполняется не в UI-потоке
+	go func() {
+		if err := modules.AskPresetVariables(profile, presetName, fynePrompter{window: window}); err != nil {
+			showApplyResult(modules.ApplyResult{}, err, window)
+			return
+		}
+		preview, err := modules.PreviewPreset(profile, presetName)
+		if err != nil {
+			showApplyResult(modules.ApplyResult{}, err, window)
+			return
+		}
+		showPreview(preview, window)
+	}()
+}
+
+// showPreview показывает изменения предпросмотра и применяет их после подтверждения
+func showPreview(preview *modules.ApplyPreview, window fyne.Window) {
+	presetName := preview.Preset
+	changes := preview.Changes()
+	if len(changes) == 0 {
+		dialog.ShowInformation(modules.T("gui.previewTitle", "Preset", presetName), modules.T("gui.previewNoChanges"), window)
+		return
+	}
+
+	text := widget.NewLabel(strings.Join(changes, "\n"))
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+	scroll := container.NewScroll(text)
+	scroll.SetMinSize(fyne.NewSize(520, 280))
+	content := container.NewBorder(widget.NewLabel(strings.Join(preview.Result.Summary(), "\n")), nil, nil, nil, scroll)
+
+	dialog.ShowCustomConfirm(modules.T("gui.previewTitle", "Preset", presetName), modules.T("gui.apply"), modules.T("gui.cancel"), content, func(confirmed bool) {
+		if confirmed {
+			applyPreview(preview, nil, window)
+		}
+	}, window)
+}
+
+// applyPreview применяет пресет по предпросмотру; при конфликтах слияния просит выбрать значения
+func applyPreview(preview *modules.ApplyPreview, resolutions modules.MergeResolutions, window fyne.Window) {
+	result, err := preview.Apply(resolutions)
+	var conflictErr *modules.MergeConflictError
+	if errors.As(err, &conflictErr) {
+		showMergeConflicts(preview, conflictErr.Conflicts, resolutions, window)
+		return
+	}
+	showApplyResult(result, err, window)
+}
+
+// showMergeConflicts показывает ключи, измененные после предпросмотра, с выбором между значением
+// пресета и значением из файла. По умолчанию выбрано значение из файла, чтобы не потерять чужие правки.
+func showMergeConflicts(preview *modules.ApplyPreview, conflicts []modules.MergeConflict, resolutions modules.MergeResolutions, window fyne.Window) {
+	chosen := make(modules.MergeResolutions, len(resolutions)+len(conflicts))
+	for id, usePreset := range resolutions {
+		chosen[id] = usePreset
+	}
+
+	rows := container.NewVBox()
+	choices := make([]*widget.RadioGroup, len(conflicts))
+	for i, conflict := range conflicts {
+		presetOption := modules.T("gui.conflictPreset", "Value", modules.DisplayValue(conflict.Preset, conflict.PresetPresent))
+		fileOption := modules.T("gui.conflictFile", "Value", modules.DisplayValue(conflict.Current, conflict.CurrentPresent))
+		choices[i] = widget.NewRadioGroup([]string{presetOption, fileOption}, nil)
+		choices[i].Horizontal = true
+		choices[i].Required = true
+		choices[i].SetSelected(fileOption)
+
+		rows.Add(widget.NewLabelWithStyle(conflict.ID(), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		rows.Add(widget.NewLabel(modules.T("gui.conflictBase", "Value", modules.DisplayValue(conflict.Base, conflict.BasePresent))))
+		rows.Add(choices[i])
+	}
+
+	scroll := container.NewScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(520, 280))
+	message := widget.NewLabel(modules.T("gui.conflictsMessage", "Count", len(conflicts)))
+	message.Wrapping = fyne.TextWrapWord
+	content := container.NewBorder(message, nil, nil, nil, scroll)
+
+	dialog.ShowCustomConfirm(modules.T("gui.conflictsTitle"), modules.T("gui.apply"), modules.T("gui.cancel"), content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		for i, conflict := range conflicts {
+			chosen[conflict.ID()] = choices[i].Selected == choices[i].Options[0]
+		}
+		applyPreview(preview, chosen, window)
+	}, window)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"BiomeManager/modules"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Значки звезды избранного: в стандартной теме Fyne их нет
+var (
+	starIcon = theme.NewThemedResource(fyne.NewStaticResource("star.svg", []byte(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><path d="M12 2l3.1 6.3 6.9 1-5 4.9 1.2 6.8L12 17.8 5.8 21l1.2-6.8-5-4.9 6.9-1z"/></svg>`)))
+	starOutlineIcon = theme.NewThemedResource(fyne.NewStaticResource("star_outline.svg", []byte(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><path fill-rule="evenodd" d="M12 2l3.1 6.3 6.9 1-5 4.9 1.2 6.8L12 17.8 5.8 21l1.2-6.8-5-4.9 6.9-1zm0 4.5l-1.8 3.7-4.1.6 3 2.9-.7 4.1 3.6-1.9 3.6 1.9-.7-4.1 3-2.9-4.1-.6z"/></svg>`)))
+)
+
+// presetBrowser - список пресетов с нечетким поиском, фильтрами по метаданным, избранным
+// и недавно примененными пресетами профиля
+type presetBrowser struct {
+	window  fyne.Window
+	profile *modules.Profile
+	catalog []modules.PresetInfo
+	shown   []modules.PresetInfo
+	filter  modules.PresetFilter
+	current string
+
+	list          *widget.List
+	selectedLabel *widget.Label
+	recent        *fyne.Container
+	tagSelect     *widget.Select
+	authorSelect  *widget.Select
+	sourceSelect  *widget.Select
+}
+
+// newPresetBrowser создает список пресетов каталога для профиля
+func newPresetBrowser(window fyne.Window, profile *modules.Profile, catalog []modules.PresetInfo) *presetBrowser {
+	b := &presetBrowser{window: window, profile: profile, catalog: catalog}
+	b.shown = modules.FilterPresets(catalog, b.filter, profile.Favorites)
+	b.selectedLabel = widget.NewLabel(modules.T("gui.noPresetSelected"))
+	b.recent = container.NewHBox()
+	b.refreshRecent()
+
+	b.list = widget.NewList(
+		func() int { return len(b.shown) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewButtonWithIcon("", starOutlineIcon, nil), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			info := b.shown[id]
+			row := item.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			star := row.Objects[1].(*widget.Button)
+
+			text := info.Name
+			if info.Description != "" {
+				text = modules.T("gui.presetWithDescription", "Name", info.Name, "Description", info.Description)
+			}
+			label.SetText(text)
+			label.Truncation = fyne.TextTruncateEllipsis
+
+			if b.profile.IsFavorite(info.Name) {
+				star.SetIcon(starIcon)
+			} else {
+				star.SetIcon(starOutlineIcon)
+			}
+			star.OnTapped = func() { b.toggleFavorite(info.Name) }
+		},
+	)
+	b.list.OnSelected = func(id widget.ListItemID) {
+		b.selectPreset(b.shown[id].Name)
+	}
+	return b
+}
+
+// Selected возвращает выбранный пресет или пустую строку
+func (b *presetBrowser) Selected() string {
+	return b.current
+}
+
+// selectPreset делает пресет выбранным
+func (b *presetBrowser) selectPreset(name string) {
+	b.current = name
+	b.selectedLabel.SetText(modules.T("gui.presetSelected", "Name", name))
+	modules.Logger.Debug("preset selected", "preset", name)
+}
+
+// toggleFavorite ставит или снимает звезду пресета и сохраняет ее в профиле
+func (b *presetBrowser) toggleFavorite(name string) {
+	if _, err := modules.ToggleFavorite(b.profile, name); err != nil {
+		dialog.ShowError(err, b.window)
+		return
+	}
+	b.applyFilter()
+}
+
+// applyFilter заново отбирает пресеты по текущему фильтру. Выбор сохраняется, даже если
+// выбранный пресет скрыт фильтром.
+func (b *presetBrowser) applyFilter() {
+	b.shown = modules.FilterPresets(b.catalog, b.filter, b.profile.Favorites)
+	b.list.UnselectAll()
+	b.list.Refresh()
+}
+
+// refreshRecent перестраивает строку недавно примененных пресетов профиля
+func (b *presetBrowser) refreshRecent() {
+	b.recent.RemoveAll()
+	if len(b.profile.Recent) == 0 {
+		b.recent.Add(widget.NewLabel(modules.T("gui.recentEmpty")))
+	}
+	for _, name := range b.profile.Recent {
+		name := name
+		button := widget.NewButton(name, func() { b.selectPreset(name) })
+		button.Importance = widget.LowImportance
+		b.recent.Add(button)
+	}
+	b.recent.Refresh()
+}
+
+// content собирает строку поиска, фильтры, недавние пресеты и список
+func (b *presetBrowser) content() fyne.CanvasObject {
+	search := widget.NewEntry()
+	search.SetPlaceHolder(modules.T("gui.searchPresets"))
+	search.OnChanged = func(query string) {
+		b.filter.Query = query
+		b.applyFilter()
+	}
+
+	b.tagSelect = b.filterSelect(func(value string) { b.filter.Tag = value })
+	b.authorSelect = b.filterSelect(func(value string) { b.filter.Author = value })
+	b.sourceSelect = b.filterSelect(func(value string) { b.filter.Source = value })
+	b.updateFilterOptions()
+
+	favoritesOnly := widget.NewCheck(modules.T("gui.favoritesOnly"), func(checked bool) {
+		b.filter.FavoritesOnly = checked
+		b.applyFilter()
+	})
+
+	filters := container.NewGridWithColumns(3,
+		container.NewBorder(nil, nil, widget.NewLabel(modules.T("gui.filterTag")), nil, b.tagSelect),
+		container.NewBorder(nil, nil, widget.NewLabel(modules.T("gui.filterAuthor")), nil, b.authorSelect),
+		container.NewBorder(nil, nil, widget.NewLabel(modules.T("gui.filterSource")), nil, b.sourceSelect),
+	)
+	recent := container.NewBorder(nil, nil, widget.NewLabel(modules.T("gui.recentPresets")), nil, container.NewHScroll(b.recent))
+
+	top := container.NewVBox(
+		container.NewBorder(nil, nil, nil, favoritesOnly, search),
+		filters,
+		recent,
+	)
+	return container.NewBorder(top, b.selectedLabel, nil, nil, b.list)
+}
+
+// filterSelect создает список значений фильтра; варианты задает updateFilterOptions
+func (b *presetBrowser) filterSelect(set func(string)) *widget.Select {
+	all := modules.T("gui.filterAll")
+	filterSelect := widget.NewSelect([]string{all}, func(value string) {
+		if value == all {
+			value = ""
+		}
+		set(value)
+		b.applyFilter()
+	})
+	filterSelect.Selected = all
+	return filterSelect
+}
+
+// updateFilterOptions задает варианты фильтров по метаданным каталога с пунктом "все" в начале
+func (b *presetBrowser) updateFilterOptions() {
+	all := modules.T("gui.filterAll")
+	tags, authors, sources := modules.PresetCatalogValues(b.catalog)
+	for _, option := range []struct {
+		filterSelect *widget.Select
+		values       []string
+	}{{b.tagSelect, tags}, {b.authorSelect, authors}, {b.sourceSelect, sources}} {
+		option.filterSelect.Options = append([]string{all}, option.values...)
+		if len(option.values) == 0 {
+			option.filterSelect.Disable()
+		} else {
+			option.filterSelect.Enable()
+		}
+		option.filterSelect.Refresh()
+	}
+}
+
+// reload перечитывает каталог, например после сохранения сгенерированного пресета
+func (b *presetBrowser) reload() {
+	catalog, err := modules.LoadPresetCatalog()
+	if err != nil {
+		dialog.ShowError(err, b.window)
+		return
+	}
+	b.catalog = catalog
+	b.updateFilterOptions()
+	b.applyFilter()
+}
+
+// Names возвращает имена всех пресетов каталога
+func (b *presetBrowser) Names() []string {
+	names := make([]string, len(b.catalog))
+	for i, info := range b.catalog {
+		names[i] = info.Name
+	}
+	return names
+}
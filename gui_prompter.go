@@ -0,0 +1,176 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// fynePrompter реализует modules.Prompter через диалоги Fyne.
+// Методы блокируются до ответа пользователя, поэтому вызывать их нужно не из UI-потока.
+type fynePrompter struct {
+	window fyne.Window
+}
+
+// ChooseDirectory показывает диалог выбора папки
+func (p fynePrompter) ChooseDirectory(title string) (string, error) {
+	result := make(chan string, 1)
+	errs := make(chan error, 1)
+
+	// Заголовок окна подсказывает, какую папку нужно выбрать
+	oldTitle := p.window.Title()
+	p.window.SetTitle(title)
+	defer p.window.SetTitle(oldTitle)
+
+	folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			errs <- err
+			return
+		}
+		if uri == nil {
+			errs <- fmt.Errorf("%s", modules.T("gui.folderCancelled"))
+			return
+		}
+		result <- uri.Path()
+	}, p.window)
+	folderDialog.Resize(fyne.NewSize(700, 500))
+	folderDialog.Show()
+
+	select {
+	case folder := <-result:
+		return folder, nil
+	case err := <-errs:
+		return "", err
+	}
+}
+
+// ChooseRedkit показывает найденные установки REDkit с версией и источником
+func (p fynePrompter) ChooseRedkit(installs []modules.RedkitInstall) (string, error) {
+	result := make(chan string, 1)
+	selected := -1
+
+	list := widget.NewList(
+		func() int { return len(installs) },
+		func() fyne.CanvasObject {
+			path := widget.NewLabel("")
+			path.TextStyle = fyne.TextStyle{Bold: true}
+			return container.NewVBox(path, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			install := installs[id]
+			labels := item.(*fyne.Container).Objects
+			labels[0].(*widget.Label).SetText(install.Path)
+
+			version := install.Version
+			if version == "" {
+				version = modules.T("redkit.versionUnknown")
+			}
+			details := fmt.Sprintf("%s · %s", install.Source, version)
+			if !install.SessionsExists {
+				details += " · " + modules.T("gui.noSessionsYet")
+			}
+			labels[1].(*widget.Label).SetText(details)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+
+	// "Указать вручную" закрывает диалог с пустым результатом, и дальше откроется выбор папки
+	redkitDialog := dialog.NewCustomConfirm(modules.T("console.redkitFound"), modules.T("gui.select"), modules.T("console.manualFolder"), list, func(ok bool) {
+		if !ok || selected < 0 {
+			result <- ""
+			return
+		}
+		result <- installs[selected].SessionsPath
+	}, p.window)
+	redkitDialog.Resize(fyne.NewSize(600, 400))
+	redkitDialog.Show()
+
+	return <-result, nil
+}
+
+// ChooseProject показывает список найденных проектов с их мирами и датой изменения и ждет выбора
+func (p fynePrompter) ChooseProject(projects []modules.ProjectInfo) (string, error) {
+	result := make(chan string, 1)
+	selected := -1
+
+	list := widget.NewList(
+		func() int { return len(projects) },
+		func() fyne.CanvasObject {
+			name := widget.NewLabel("")
+			name.TextStyle = fyne.TextStyle{Bold: true}
+			details := widget.NewLabel("")
+			return container.NewVBox(name, details)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			project := projects[id]
+			labels := item.(*fyne.Container).Objects
+			labels[0].(*widget.Label).SetText(project.Name)
+
+			worlds := modules.T("project.noWorlds")
+			if len(project.Worlds) > 0 {
+				worlds = modules.T("project.worlds", "Worlds", strings.Join(project.Worlds, ", "))
+			}
+			modified := modules.T("project.modified", "Time", project.ModTime.Format("2006-01-02 15:04"))
+			labels[1].(*widget.Label).SetText(worlds + " · " + modified)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+
+	projectDialog := dialog.NewCustomConfirm(modules.T("gui.chooseProject"), modules.T("gui.select"), modules.T("gui.cancel"), list, func(ok bool) {
+		if !ok || selected < 0 {
+			result <- ""
+			return
+		}
+		result <- projects[selected].Name
+	}, p.window)
+	projectDialog.Resize(fyne.NewSize(500, 400))
+	projectDialog.Show()
+
+	project := <-result
+	if project == "" {
+		return "", fmt.Errorf("%s", modules.T("gui.noProjectSelected"))
+	}
+	return project, nil
+}
+
+// AskVariables показывает форму со значениями переменных шаблона пресета и ждет ответа
+func (p fynePrompter) AskVariables(presetName string, variables []modules.PresetVariable) (map[string]string, error) {
+	result := make(chan bool, 1)
+	entries := make([]*widget.Entry, len(variables))
+	items := make([]*widget.FormItem, len(variables))
+	for i, variable := range variables {
+		entries[i] = widget.NewEntry()
+		entries[i].Validator = func(text string) error {
+			if strings.TrimSpace(text) == "" {
+				return fmt.Errorf("%s", modules.T("gui.variableRequired"))
+			}
+			return nil
+		}
+		items[i] = widget.NewFormItem(variable.Name, entries[i])
+		items[i].HintText = variable.Description
+	}
+
+	form := dialog.NewForm(modules.T("gui.variablesTitle", "Preset", presetName), modules.T("gui.apply"), modules.T("gui.cancel"), items, func(ok bool) {
+		result <- ok
+	}, p.window)
+	form.Resize(fyne.NewSize(420, 0))
+	form.Show()
+
+	if !<-result {
+		return nil, fmt.Errorf("%s", modules.T("gui.variablesCancelled"))
+	}
+	values := make(map[string]string, len(variables))
+	for i, variable := range variables {
+		values[variable.Name] = strings.TrimSpace(entries[i].Text)
+	}
+	return values, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newPublishButton создает кнопку публикации выбранного пресета в репозиторий пресетов через pull request
+func newPublishButton(window fyne.Window, selected func() string) *widget.Button {
+	return widget.NewButton(modules.T("gui.publish"), func() {
+		presetName := selected()
+		if presetName == "" {
+			dialog.ShowInformation(modules.T("gui.noPresetTitle"), modules.T("gui.noPresetMessage"), window)
+			return
+		}
+		publication, err := modules.PreparePublication(presetName)
+		if err != nil {
+			showApplyResult(modules.ApplyResult{}, err, window)
+			return
+		}
+		settings, err := modules.LoadGitHubSettings()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		showPublishDialog(publication, settings, window)
+	})
+}
+
+// showPublishDialog показывает заголовок и описание pull request (их можно поправить) и файлы,
+// затем публикует пресет в фоне и показывает ссылку на pull request
+func showPublishDialog(publication *modules.PresetPublication, settings modules.GitHubSettings, window fyne.Window) {
+	title := widget.NewEntry()
+	title.SetText(publication.Title)
+	body := widget.NewMultiLineEntry()
+	body.SetText(publication.Body)
+	body.SetMinRowsVisible(6)
+
+	files := make([]string, len(publication.Files))
+	for i, file := range publication.Files {
+		files[i] = file.Path
+	}
+	items := []*widget.FormItem{
+		widget.NewFormItem(modules.T("gui.publishRepository"), widget.NewLabel(settings.Repository)),
+		widget.NewFormItem(modules.T("gui.publishFiles"), widget.NewLabel(strings.Join(files, "\n"))),
+		widget.NewFormItem(modules.T("gui.publishPRTitle"), title),
+		widget.NewFormItem(modules.T("gui.publishPRBody"), body),
+	}
+
+	form := dialog.NewForm(modules.T("gui.publishTitle", "Preset", publication.Preset), modules.T("gui.publish"), modules.T("gui.cancel"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		publication.Title = title.Text
+		publication.Body = body.Text
+
+		progress := dialog.NewCustomWithoutButtons(modules.T("gui.publishing"), widget.NewProgressBarInfinite(), window)
+		progress.Show()
+		go func() {
+			result, err := modules.PublishPreset(settings, publication)
+			progress.Hide()
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			showPublishResult(result, window)
+		}()
+	}, window)
+	form.Resize(fyne.NewSize(560, 0))
+	form.Show()
+}
+
+// showPublishResult показывает ссылку на созданный pull request
+func showPublishResult(result *modules.PublishResult, window fyne.Window) {
+	message := widget.NewLabel(modules.T("gui.published", "Repository", result.Repository, "Branch", result.Branch))
+	content := container.NewVBox(message)
+	if link, err := url.Parse(result.URL); err == nil {
+		content.Add(widget.NewHyperlink(result.URL, link))
+	}
+	dialog.ShowCustom(modules.T("gui.publishedTitle"), modules.T("gui.close"), content, window)
+}
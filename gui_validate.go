@@ -0,0 +1,43 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newValidateButton создает кнопку проверки локальных пресетов всех семейств
+func newValidateButton(window fyne.Window, profile *modules.Profile) *widget.Button {
+	return widget.NewButton(modules.T("gui.validate"), func() {
+		var folders []string
+		for _, family := range modules.PresetFamilies {
+			if modules.FileExists(family.Folder) {
+				folders = append(folders, family.Folder)
+			}
+		}
+
+		issues, err := modules.LintPresets(folders, modules.LintOptions{TextureCount: profile.TextureCount})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if len(issues) == 0 {
+			dialog.ShowInformation(modules.T("gui.validateTitle"), modules.T("gui.validateClean"), window)
+			return
+		}
+
+		lines := make([]string, len(issues))
+		for i, issue := range issues {
+			lines[i] = issue.String()
+		}
+		text := widget.NewLabel(strings.Join(lines, "\n"))
+		text.TextStyle = fyne.TextStyle{Monospace: true}
+		scroll := container.NewScroll(text)
+		scroll.SetMinSize(fyne.NewSize(560, 300))
+		dialog.ShowCustom(modules.T("gui.validateTitle"), modules.T("gui.close"), scroll, window)
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"BiomeManager/modules"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newVaryButton создает кнопку создания вариантов пресета со случайными отклонениями;
+// onSaved вызывается после сохранения вариантов
+func newVaryButton(window fyne.Window, presets func() []string, onSaved func()) *widget.Button {
+	return widget.NewButton(modules.T("gui.vary"), func() {
+		showVaryDialog(window, presets(), onSaved)
+	})
+}
+
+// showVaryDialog спрашивает пресет, имя, зерно, число вариантов и отклонения полей,
+// затем сохраняет варианты в папку пресетов биомов
+func showVaryDialog(window fyne.Window, presets []string, onSaved func()) {
+	presetSelect := widget.NewSelect(presets, nil)
+	name := widget.NewEntry()
+	name.Validator = modules.ValidatePresetName
+	presetSelect.OnChanged = func(selected string) {
+		if name.Text == "" {
+			name.SetText(selected + "_variant")
+		}
+	}
+
+	seed := widget.NewEntry()
+	seed.SetText(strconv.FormatInt(modules.NewSeed(), 10))
+	seed.Validator = func(text string) error {
+		if value, err := strconv.ParseInt(text, 10, 64); err != nil || value < 0 {
+			return errors.New(modules.T("generator.badNumber", "Value", text))
+		}
+		return nil
+	}
+	newSeed := widget.NewButton(modules.T("gui.varyNewSeed"), func() {
+		seed.SetText(strconv.FormatInt(modules.NewSeed(), 10))
+	})
+	count := intEntry(1)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(modules.T("gui.varyPreset"), presetSelect),
+		widget.NewFormItem(modules.T("gui.name"), name),
+		widget.NewFormItem(modules.T("gui.varySeed"), container.NewBorder(nil, nil, nil, newSeed, seed)),
+		widget.NewFormItem(modules.T("gui.varyCount"), count),
+	}
+	jitterEntries := make(map[string]*widget.Entry)
+	for _, key := range modules.JitterKeys() {
+		jitterEntries[key] = floatEntry(modules.DefaultJitter[key])
+		items = append(items, widget.NewFormItem(modules.T("gui.varyJitter", "Key", key), jitterEntries[key]))
+	}
+	items[2].HintText = modules.T("gui.varySeedHint")
+
+	dialog.ShowForm(modules.T("gui.varyTitle"), modules.T("gui.save"), modules.T("gui.cancel"), items, func(ok bool) {
+		if !ok || presetSelect.Selected == "" {
+			return
+		}
+		jitter := make(map[string]float64, len(jitterEntries))
+		for key, entry := range jitterEntries {
+			jitter[key], _ = strconv.ParseFloat(entry.Text, 64)
+		}
+		firstSeed, _ := strconv.ParseInt(seed.Text, 10, 64)
+		variants, _ := strconv.Atoi(count.Text)
+
+		var saved []string
+		for i := 0; i < max(variants, 1); i++ {
+			path, err := saveVariant(presetSelect.Selected, name.Text, i, variants, firstSeed+int64(i), jitter)
+			if err != nil {
+				dialog.ShowError(err, window)
+				break
+			}
+			saved = append(saved, modules.T("cli.varied", "Path", path, "Seed", firstSeed+int64(i)))
+		}
+		if len(saved) > 0 {
+			dialog.ShowInformation(modules.T("gui.presetSavedTitle"), strings.Join(saved, "\n"), window)
+			if onSaved != nil {
+				onSaved()
+			}
+		}
+	}, window)
+}
+
+// saveVariant создает и сохраняет i-й из count вариантов; при нескольких вариантах к имени добавляется номер
+func saveVariant(preset, name string, i, count int, seed int64, jitter map[string]float64) (string, error) {
+	variant, err := modules.VaryPreset(preset, seed, jitter)
+	if err != nil {
+		return "", err
+	}
+	variant.Metadata.Name = name
+	if count > 1 {
+		variant.Metadata.Name = fmt.Sprintf("%s_%d", name, i+1)
+	}
+	return modules.SavePreset(variant, "", false)
+}
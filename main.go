@@ -2,66 +2,187 @@ package main
 
 import (
 	"BiomeManager/modules"
+	"flag"
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
-	"log"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 func main() {
+	// Язык ОС нужен уже для справки по флагам; настройка из config.json применяется после загрузки
+	if err := modules.InitLocale(""); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
 
-	// Получение путей к REDkit и проектному имени, но игнорируем workspacePath
-	fullPath, _, projectName, err := modules.GetPaths()
-	if err != nil {
-		log.Fatalf("Ошибка при получении путей: %v", err)
+	// Подкоманды (например, apply) работают без окна
+	if len(os.Args) > 1 {
+		if _, ok := cliCommands[os.Args[1]]; ok {
+			os.Exit(runCLI(os.Args[1], os.Args[2:]))
+		}
+	}
+
+	profileName := flag.String("profile", "", modules.T("flag.profile"))
+	configFile := flag.String("config", "", modules.T("flag.config"))
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), modules.T("usage.main", "Program", filepath.Base(os.Args[0])))
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\n%s\n  %s\n", modules.T("usage.env"), strings.Join(modules.EnvOverrideNames(), "\n  "))
+		fmt.Fprintf(flag.CommandLine.Output(), "\n%s\n", modules.T("usage.commands"))
+		for _, name := range cliCommandNames() {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %s\n", modules.T(cliCommands[name].usage))
+		}
+	}
+	flag.Parse()
+	modules.SetConfigPath(*configFile)
+
+	// Журнал пишется в файл рядом с config.json; уровень берется из конфигурации
+	config, _ := modules.LoadConfig()
+	if err := modules.InitLogging(config); err != nil {
+		modules.Logger.Warn("logging setup failed", "error", err)
+	}
+	if config != nil && config.Language != "" {
+		if err := modules.InitLocale(config.Language); err != nil {
+			modules.Logger.Warn("locale setup failed", "error", err)
+		}
 	}
-	fmt.Println(fullPath, projectName)
+
 	// Инициализируем приложение
 	myApp := app.New()
-	myWindow := myApp.NewWindow("Biome Preset Selector")
+	myWindow := myApp.NewWindow(modules.T("app.title"))
+	myWindow.Resize(fyne.NewSize(720, 560))
+
+	// Пути настраиваются через диалоги в этом же окне, поэтому загрузка идет в фоне
+	go showProfile(myWindow, *profileName)
+
+	myWindow.ShowAndRun()
+}
 
-	// Загружаем пресеты из GitHub и конвертируем их в TXT
-	err = modules.FetchAndConvertBiomeBrushes()
+// showProfile загружает профиль (при необходимости запрашивая пути) и заменяет содержимое окна
+func showProfile(myWindow fyne.Window, profileName string) {
+	myWindow.SetContent(widget.NewLabel(modules.T("gui.loading")))
+
+	content, err := loadMainContent(myWindow, profileName)
 	if err != nil {
-		log.Fatalf("Ошибка при загрузке пресетов с GitHub: %v", err)
+		modules.Logger.Error("startup failed", "profile", profileName, "error", err)
+		dialog.ShowError(err, myWindow)
+		return
 	}
+	myWindow.SetContent(content)
+}
 
-	// Загружаем список доступных пресетов из локальной папки
-	presets, err := modules.FetchAvailablePresets()
+// loadMainContent настраивает пути профиля, загружает пресеты и собирает основной интерфейс
+func loadMainContent(myWindow fyne.Window, profileName string) (fyne.CanvasObject, error) {
+	prompter := fynePrompter{window: myWindow}
+
+	// Получение путей к REDkit и проекта из профиля
+	profile, err := modules.GetPaths(profileName, prompter)
 	if err != nil {
-		log.Fatalf("Ошибка при получении списка пресетов: %v", err)
+		return nil, fmt.Errorf("%s: %v", modules.T("error.getPaths"), err)
 	}
 
-	// Создаем выпадающий список с пресетами
-	presetSelect := widget.NewSelect(presets, func(selected string) {
-		fmt.Println("Выбран пресет:", selected)
-	})
+	// Загружаем пресеты из GitHub и сохраняем их в собственном формате
+	err = modules.FetchPresets(profile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", modules.T("error.loadPresets"), err)
+	}
+
+	// Загружаем каталог локальных пресетов с метаданными для поиска и фильтров
+	catalog, err := modules.LoadPresetCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", modules.T("error.listPresets"), err)
+	}
+	browser := newPresetBrowser(myWindow, profile, catalog)
 
 	// Кнопка для применения выбранного пресета
-	applyButton := widget.NewButton("Применить пресет", func() {
-		selectedPreset := presetSelect.Selected
+	applyButton := widget.NewButton(modules.T("gui.apply"), func() {
+		selectedPreset := browser.Selected()
 		if selectedPreset != "" {
-			// Формируем путь к выбранному пресету
-			txtFilePath := fmt.Sprintf("presets/%s.txt", selectedPreset)
-
-			// Выполняем замену блоков в r4LavaEditor2.sessions.ini на основе выбранного пресета
-			modules.ReplaceBlocksInIni(fullPath, txtFilePath, projectName)
+			// Показываем изменения, затем делаем резервную копию и сливаем блоки пресета с r4LavaEditor2.sessions.ini
+			previewAndApply(profile, selectedPreset, myWindow)
 		} else {
-			fmt.Println("Пожалуйста, выберите пресет для применения.")
+			dialog.ShowInformation(modules.T("gui.noPresetTitle"), modules.T("gui.noPresetMessage"), myWindow)
 		}
 	})
 
-	// Создаем интерфейс с выбором пресета и кнопкой для его применения
-	content := container.NewVBox(
-		widget.NewLabel("Выберите пресет для применения:"),
-		presetSelect,
+	// Текущий проект и кнопка для его смены без перезапуска
+	projectLabel := widget.NewLabel(modules.T("gui.project", "Name", profile.ProjectName))
+	var switchButton *widget.Button
+	switchButton = widget.NewButton(modules.T("gui.switchProject"), func() {
+		switchButton.Disable()
+		go func() {
+			defer switchButton.Enable()
+			changed, err := modules.ChangeProject(profile.Name, prompter)
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			profile.ProjectName = changed.ProjectName
+			profile.World = changed.World
+			projectLabel.SetText(modules.T("gui.project", "Name", profile.ProjectName))
+		}()
+	})
+
+	// Сверху профиль и проект, в центре список пресетов, под ним действия, история и журнал
+	header := container.NewVBox(
+		profileSwitcher(myWindow, profile.Name),
+		container.NewBorder(nil, nil, nil, switchButton, projectLabel),
+		widget.NewLabel(modules.T("gui.choosePreset")),
+	)
+	actions := container.NewGridWithColumns(3,
 		applyButton,
+		newBatchButton(myWindow, profile),
+		newGenerateButton(myWindow, profile, browser.reload),
+		newBlendButton(myWindow, profile, browser.Names, browser.reload),
+		newVaryButton(myWindow, browser.Names, browser.reload),
+		newCompareButton(myWindow, browser.Names),
+		newInheritanceButton(myWindow, browser.Selected),
+		newPublishButton(myWindow, browser.Selected),
+		newValidateButton(myWindow, profile),
 	)
+	bottom := container.NewVBox(actions, newHistoryPanel(myWindow, browser.refreshRecent), newLogPanel())
+	return container.NewBorder(header, bottom, nil, nil, browser.content()), nil
+}
 
-	// Настраиваем окно и запускаем интерфейс
-	myWindow.SetContent(content)
-	myWindow.Resize(fyne.NewSize(400, 200))
-	myWindow.ShowAndRun()
+// profileSwitcher собирает строку выбора профиля и кнопку создания нового профиля
+func profileSwitcher(myWindow fyne.Window, current string) fyne.CanvasObject {
+	var names []string
+	if config, err := modules.LoadConfig(); err == nil {
+		names = config.ProfileNames()
+	}
+
+	profileSelect := widget.NewSelect(names, nil)
+	profileSelect.Selected = current
+	profileSelect.OnChanged = func(name string) {
+		if name == current {
+			return
+		}
+		go func() {
+			if err := modules.SetActiveProfile(name); err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			showProfile(myWindow, name)
+		}()
+	}
+
+	newButton := widget.NewButton(modules.T("gui.newProfile"), func() {
+		nameEntry := widget.NewEntry()
+		dialog.ShowForm(modules.T("gui.newProfile"), modules.T("gui.create"), modules.T("gui.cancel"), []*widget.FormItem{
+			widget.NewFormItem(modules.T("gui.name"), nameEntry),
+		}, func(ok bool) {
+			if !ok || nameEntry.Text == "" {
+				return
+			}
+			// Для нового профиля GetPaths запросит пути и проект
+			go showProfile(myWindow, nameEntry.Text)
+		}, myWindow)
+	})
+
+	return container.NewBorder(nil, nil, widget.NewLabel(modules.T("gui.profile")), newButton, profileSelect)
 }
@@ -0,0 +1,206 @@
+package modules
+
+import "slices"
+
+// ApplyPreview - предпросмотр применения пресета. Хранит значения ключей на момент
+// предпросмотра (базу) вместе со значениями пресета, чтобы при применении слить изменения пресета с правками, которые
+// REDkit или чужой скрипт внесли в файл после предпросмотра.
+type ApplyPreview struct {
+	Preset string
+	Result ApplyResult // Что применение сделает с базой (без пути к резервной копии)
+
+	profile *Profile
+	patch   *Patch // Изменения ключей от базы к результату применения
+}
+
+// MergeConflict - ключ, который после предпросмотра изменили в файле иначе, чем пресет.
+// Признаки *Present ложны, если ключа в соответствующем состоянии нет.
+type MergeConflict struct {
+	Header         string
+	Key            string
+	Base           string // Значение на момент предпросмотра
+	Preset         string // Значение, которое записывает пресет
+	Current        string // Значение в файле сейчас
+	BasePresent    bool
+	PresetPresent  bool
+	CurrentPresent bool
+}
+
+// ID возвращает идентификатор конфликта "заголовок ключ" для выбора решения
+func (c MergeConflict) ID() string {
+	return c.Header + " " + c.Key
+}
+
+// MergeResolutions - решения по конфликтам: по ID конфликта true - взять значение пресета,
+// false - оставить значение из файла
+type MergeResolutions map[string]bool
+
+// PreviewPreset читает файл сессий и вычисляет, что изменит применение пресета, не записывая файл
+func PreviewPreset(profile *Profile, presetName string) (*ApplyPreview, error) {
+	presetBlocks, err := loadPresetFamilies(presetName, profile.TemplateValues())
+	if err != nil {
+		return nil, err
+	}
+	return previewBlocks(profile, presetName, presetBlocks)
+}
+
+// PreviewPresetFile делает предпросмотр пресета, которого может не быть в папке пресетов
+// (например, результата смешивания); в истории он записывается под именем из метаданных
+func PreviewPresetFile(profile *Profile, file *PresetFile) (*ApplyPreview, error) {
+	blocks := file.blocks()
+	if err := validatePreset(file.Metadata.Name, blocks, nil); err != nil {
+		return nil, err
+	}
+	return previewBlocks(profile, file.Metadata.Name, blocks)
+}
+
+// previewBlocks вычисляет изменения файла сессий от применения проверенных блоков
+func previewBlocks(profile *Profile, presetName string, presetBlocks map[string]PresetBlock) (*ApplyPreview, error) {
+	doc, err := openSessionsForApply(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	base := doc.clone()
+	result := applyToWorld(doc, profile, profile.ProjectName, profile.WorldName(), presetBlocks)
+	return &ApplyPreview{
+		Preset:  presetName,
+		Result:  result,
+		profile: profile,
+		patch:   diffDocuments(base, doc),
+	}, nil
+}
+
+// Changes возвращает строки изменений ключей "секция ключ: было → станет"
+func (p *ApplyPreview) Changes() []string {
+	var lines []string
+	for _, section := range p.patch.Sections {
+		for _, change := range section.Keys {
+			lines = append(lines, T("preview.change",
+				"Header", section.Header,
+				"Key", change.Key,
+				"Before", DisplayValue(change.Before, change.Had),
+				"After", DisplayValue(change.After, change.Has),
+			))
+		}
+	}
+	return lines
+}
+
+// Apply записывает изменения пресета в файл сессий с трехсторонним слиянием по ключам:
+// ключи, которые после предпросмотра никто не трогал, получают значение пресета, а чужие правки
+// остальных ключей сохраняются. Если ключ изменили и в файле, и в пресете по-разному, а решения
+// в resolutions для него нет, файл не меняется и возвращается *MergeConflictError со всеми конфликтами.
+// Итог строится по тому, что слияние действительно записало, а не по предпросмотру.
+func (p *ApplyPreview) Apply(resolutions MergeResolutions) (ApplyResult, error) {
+	doc, err := openSessionsForApply(p.profile)
+	if err != nil {
+		return ApplyResult{}, err
+	}
+	before := doc.clone()
+
+	var conflicts []MergeConflict
+	for _, section := range p.patch.Sections {
+		conflicts = append(conflicts, section.merge(doc, resolutions)...)
+	}
+	if len(conflicts) > 0 {
+		Logger.Warn("preset apply conflicts", "preset", p.Preset, "path", p.profile.FilePath, "conflicts", len(conflicts))
+		return ApplyResult{}, &MergeConflictError{Path: p.profile.FilePath, Conflicts: conflicts}
+	}
+
+	result := p.mergedResult(before, doc)
+	if result.Changed() {
+		result.BackupPath, err = saveAppliedDocument(p.profile, doc)
+		if err != nil {
+			return result, err
+		}
+		// Недавние обновляются до записи в историю: окно перечитывает их по событию истории
+		rememberApplied(p.profile, p.Preset)
+		ApplyHistory.record(p.profile, p.Preset, before, doc)
+	}
+	logPresetApplied(p.profile, p.Preset, result)
+
+	if len(result.SlotsMissing) > 0 {
+		return result, &SlotMissingError{World: p.profile.WorldName(), Slots: result.SlotsMissing}
+	}
+	return result, nil
+}
+
+// mergedResult собирает итог применения по изменениям между документом до слияния и после него:
+// секции, которые слияние не изменило, в итог не попадают. Слоты, которые предпросмотр сбрасывал
+// как лишние, остаются сброшенными; недостающие слоты и неизвестные ключи берутся из предпросмотра.
+func (p *ApplyPreview) mergedResult(before, after *sessionDocument) ApplyResult {
+	result := ApplyResult{SlotsMissing: p.Result.SlotsMissing, UnknownKeys: p.Result.UnknownKeys}
+	for _, section := range diffDocuments(before, after).Sections {
+		ref, known := kindOf(section.Header)
+		if !known {
+			continue
+		}
+		if ref.Kind == materialPairKind && slices.Contains(p.Result.SlotsReset, ref.label()) {
+			result.SlotsReset = append(result.SlotsReset, ref.label())
+			continue
+		}
+		result.addSection(ref, section.Created)
+	}
+	return result
+}
+
+// merge сливает изменения секции с текущим документом. Для каждого ключа:
+// в файле уже значение пресета - ничего не делать; в файле значение базы - записать значение пресета;
+// иначе это конфликт, который решается по resolutions или возвращается.
+func (sp sectionPatch) merge(doc *sessionDocument, resolutions MergeResolutions) []MergeConflict {
+	section := doc.section(sp.Header)
+	values := map[string]string{}
+	if section != nil {
+		values = section.Values
+	}
+
+	var conflicts []MergeConflict
+	for _, change := range sp.Keys {
+		current, present := values[change.Key]
+		if present == change.Has && (!present || current == change.After) {
+			continue
+		}
+		if present != change.Had || (present && current != change.Before) {
+			conflict := MergeConflict{
+				Header:         sp.Header,
+				Key:            change.Key,
+				Base:           change.Before,
+				Preset:         change.After,
+				Current:        current,
+				BasePresent:    change.Had,
+				PresetPresent:  change.Has,
+				CurrentPresent: present,
+			}
+			usePreset, resolved := resolutions[conflict.ID()]
+			if !resolved {
+				conflicts = append(conflicts, conflict)
+				continue
+			}
+			if !usePreset {
+				continue
+			}
+		}
+
+		// Секцию, удаленную после предпросмотра или созданную пресетом, нужно вернуть на место
+		if section == nil {
+			section = &iniSection{Header: sp.Header, Values: make(map[string]string)}
+			doc.insertAfter(sp.Anchor, section)
+			values = section.Values
+		}
+		if change.Has {
+			section.set(change.Key, change.After)
+		} else {
+			section.removeKey(change.Key)
+		}
+	}
+	return conflicts
+}
+
+// DisplayValue возвращает значение ключа для показа или пометку об отсутствии ключа
+func DisplayValue(value string, present bool) string {
+	if !present {
+		return T("preview.absent")
+	}
+	return value
+}
@@ -0,0 +1,147 @@
+package modules
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+var previewTestSessions = sessionsText(
+	testSection("Tools/TerrainEdit"),
+	"Size=10",
+	"Intensity=0.5",
+	"Falloff=0.3",
+)
+
+// previewTestBlocks - пресет, который меняет Size и Intensity и добавляет инструмент Smooth
+func previewTestBlocks() map[string]PresetBlock {
+	tool, smooth := testSection("Tools/TerrainEdit"), testSection("Tools/TerrainEdit/Smooth")
+	return map[string]PresetBlock{
+		tool:   {Header: tool, Keys: []string{"Size", "Intensity"}, Values: map[string]string{"Size": "25", "Intensity": "0.8"}},
+		smooth: {Header: smooth, Keys: []string{"Size"}, Values: map[string]string{"Size": "5"}},
+	}
+}
+
+// sectionValue возвращает значение ключа секции из файла сессий профиля
+func sectionValue(t *testing.T, profile *Profile, header, key string) string {
+	t.Helper()
+	doc, err := readSessionDocument(profile.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	section := doc.section(header)
+	if section == nil {
+		t.Fatalf("нет секции %s", header)
+	}
+	return section.Values[key]
+}
+
+func TestApplyPreviewWithoutChangesAfterPreview(t *testing.T) {
+	profile := newTestProfile(t, previewTestSessions)
+	preview, err := previewBlocks(profile, "forest", previewTestBlocks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(preview.Changes()); got != 3 {
+		t.Errorf("в предпросмотре %d изменений, ожидалось 3: %v", got, preview.Changes())
+	}
+	if readTestSessions(t, profile) != previewTestSessions {
+		t.Fatal("предпросмотр изменил файл")
+	}
+
+	result, err := preview.Apply(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(result.ToolsReplaced, []string{"TerrainEdit"}) || !slices.Equal(result.ToolsInserted, []string{"Smooth"}) {
+		t.Errorf("итог %+v", result)
+	}
+	if got := sectionValue(t, profile, testSection("Tools/TerrainEdit"), "Intensity"); got != "0.8" {
+		t.Errorf("Intensity = %q, ожидалось 0.8", got)
+	}
+	if !ApplyHistory.CanUndo() {
+		t.Error("применение не записано в историю")
+	}
+}
+
+func TestApplyPreviewMergesLaterEdits(t *testing.T) {
+	profile := newTestProfile(t, previewTestSessions)
+	preview, err := previewBlocks(profile, "forest", previewTestBlocks())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// После предпросмотра в файле поменяли ключ, которого пресет не касается
+	editTestSessions(t, profile, func(doc *sessionDocument) {
+		doc.section(testSection("Tools/TerrainEdit")).set("Falloff", "0.9")
+	})
+
+	if _, err := preview.Apply(nil); err != nil {
+		t.Fatal(err)
+	}
+	tool := testSection("Tools/TerrainEdit")
+	if got := sectionValue(t, profile, tool, "Falloff"); got != "0.9" {
+		t.Errorf("правка после предпросмотра потеряна: Falloff = %q", got)
+	}
+	if got := sectionValue(t, profile, tool, "Size"); got != "25" {
+		t.Errorf("Size = %q, ожидалось значение пресета 25", got)
+	}
+}
+
+func TestApplyPreviewConflicts(t *testing.T) {
+	tool := testSection("Tools/TerrainEdit")
+	conflictID := tool + " Intensity"
+
+	tests := []struct {
+		name          string
+		usePreset     bool
+		intensity     string
+		toolsReplaced []string
+	}{
+		// Size уже равен значению пресета, поэтому при сохранении значения из файла секция не меняется
+		{"значение из файла", false, "0.6", nil},
+		{"значение пресета", true, "0.8", []string{"TerrainEdit"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := newTestProfile(t, previewTestSessions)
+			preview, err := previewBlocks(profile, "forest", previewTestBlocks())
+			if err != nil {
+				t.Fatal(err)
+			}
+			editTestSessions(t, profile, func(doc *sessionDocument) {
+				section := doc.section(tool)
+				section.set("Intensity", "0.6")
+				section.set("Size", "25")
+			})
+			edited := readTestSessions(t, profile)
+
+			_, err = preview.Apply(nil)
+			var conflictErr *MergeConflictError
+			if !errors.As(err, &conflictErr) {
+				t.Fatalf("ожидался конфликт, получено %v", err)
+			}
+			if len(conflictErr.Conflicts) != 1 {
+				t.Fatalf("конфликты: %+v", conflictErr.Conflicts)
+			}
+			conflict := conflictErr.Conflicts[0]
+			if conflict.ID() != conflictID || conflict.Base != "0.5" || conflict.Preset != "0.8" || conflict.Current != "0.6" {
+				t.Errorf("конфликт %+v", conflict)
+			}
+			if readTestSessions(t, profile) != edited {
+				t.Fatal("файл изменен, хотя есть нерешенный конфликт")
+			}
+
+			result, err := preview.Apply(MergeResolutions{conflictID: tt.usePreset})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sectionValue(t, profile, tool, "Intensity"); got != tt.intensity {
+				t.Errorf("Intensity = %q, ожидалось %q", got, tt.intensity)
+			}
+			if !slices.Equal(result.ToolsReplaced, tt.toolsReplaced) || !slices.Equal(result.ToolsInserted, []string{"Smooth"}) {
+				t.Errorf("итог не соответствует записанному: %+v", result)
+			}
+		})
+	}
+}
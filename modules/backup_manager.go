@@ -0,0 +1,108 @@
+package modules
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Папка для резервных копий по умолчанию (создается рядом с файлом сессий)
+const defaultBackupFolder = "MaterialBrushChanger_backups"
+
+// BackupSettings задает, делать ли резервные копии файла сессий перед изменением и сколько их хранить
+type BackupSettings struct {
+	Enabled bool   `json:"enabled"`        // Делать копию перед каждым применением пресета
+	Dir     string `json:"dir,omitempty"`  // Папка для копий (пусто - рядом с файлом сессий)
+	Keep    int    `json:"keep,omitempty"` // Сколько последних копий хранить (0 - все)
+}
+
+// Настройки резервного копирования для новых профилей
+func defaultBackupSettings() BackupSettings {
+	return BackupSettings{Enabled: true, Keep: 20}
+}
+
+// backupDir возвращает папку для резервных копий указанного файла
+func (b BackupSettings) backupDir(filePath string) string {
+	if b.Dir != "" {
+		return b.Dir
+	}
+	return filepath.Join(filepath.Dir(filePath), defaultBackupFolder)
+}
+
+// CreateBackup копирует файл в папку резервных копий и удаляет самые старые копии сверх лимита.
+// Возвращает путь к созданной копии или пустую строку, если копирование отключено.
+func CreateBackup(filePath string, settings BackupSettings) (string, error) {
+	if !settings.Enabled {
+		return "", nil
+	}
+
+	dir := settings.backupDir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("%s: %v", T("error.backupDir"), err)
+	}
+
+	base := filepath.Base(filePath)
+	backupPath := filepath.Join(dir, fmt.Sprintf("%s.%s.bak", base, time.Now().Format("20060102-150405.000")))
+	if err := copyFile(filePath, backupPath); err != nil {
+		return "", fmt.Errorf("%s: %v", T("error.backupCreate"), err)
+	}
+
+	if settings.Keep > 0 {
+		if err := pruneBackups(dir, base, settings.Keep); err != nil {
+			Logger.Warn("failed to prune old backups", "dir", dir, "error", err)
+		}
+	}
+
+	return backupPath, nil
+}
+
+// pruneBackups оставляет только keep последних копий файла base
+func pruneBackups(dir, base string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, base+".") && strings.HasSuffix(name, ".bak") {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= keep {
+		return nil
+	}
+
+	// Метка времени в имени сортируется лексикографически
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile копирует содержимое файла src в новый файл dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,206 @@
+package modules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BatchJob - задание пакетного применения: пресеты для нескольких миров и проектов за один проход
+type BatchJob struct {
+	Profile string        `json:"profile,omitempty" yaml:"profile,omitempty"` // Профиль (пусто - активный или --profile)
+	Preset  string        `json:"preset,omitempty" yaml:"preset,omitempty"`   // Пресет для целей, где он не указан
+	Targets []BatchTarget `json:"targets" yaml:"targets"`
+}
+
+// BatchTarget - мир проекта, к которому применяется пресет
+type BatchTarget struct {
+	Project string `json:"project,omitempty" yaml:"project,omitempty"` // Пусто - проект профиля
+	World   string `json:"world,omitempty" yaml:"world,omitempty"`     // Пусто - совпадает с проектом
+	Preset  string `json:"preset,omitempty" yaml:"preset,omitempty"`   // Пусто - пресет задания
+}
+
+// BatchTargetResult - итог применения к одному миру
+type BatchTargetResult struct {
+	Project string
+	World   string
+	Preset  string
+	Result  ApplyResult
+}
+
+// BatchResult - общий отчет пакетного применения с одной резервной копией
+type BatchResult struct {
+	Targets    []BatchTargetResult
+	BackupPath string
+}
+
+// Changed сообщает, был ли изменен файл сессий
+func (r BatchResult) Changed() bool {
+	for _, target := range r.Targets {
+		if target.Result.Changed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary возвращает строки общего отчета: по блоку на каждый мир и путь к резервной копии
+func (r BatchResult) Summary() []string {
+	var lines []string
+	for _, target := range r.Targets {
+		lines = append(lines, T("batch.target", "Project", target.Project, "World", target.World, "Preset", target.Preset))
+		for _, line := range target.Result.Summary() {
+			lines = append(lines, "  "+line)
+		}
+		if len(target.Result.SlotsMissing) > 0 {
+			lines = append(lines, "  "+T("batch.slotsMissing", "Slots", strings.Join(target.Result.SlotsMissing, ", ")))
+		}
+	}
+	if r.BackupPath != "" {
+		lines = append(lines, T("result.backup", "Path", r.BackupPath))
+	}
+	return lines
+}
+
+// LoadBatchJob читает задание из файла JSON или YAML (.yaml, .yml) и проверяет его по схеме
+func LoadBatchJob(path string) (*BatchJob, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.batchRead", "Path", path), err)
+	}
+
+	// YAML переводится в JSON, чтобы схема и разбор были общими для обоих форматов
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".yaml" || ext == ".yml" {
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("%s: %v", T("error.batchParse", "Path", path), err)
+		}
+		if data, err = json.Marshal(document); err != nil {
+			return nil, fmt.Errorf("%s: %v", T("error.batchParse", "Path", path), err)
+		}
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.batchParse", "Path", path), err)
+	}
+	if err := validateAgainstSchema("batch_job.schema.json", raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", T("error.batchInvalid", "Path", path), err)
+	}
+
+	var job BatchJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.batchParse", "Path", path), err)
+	}
+	if len(job.Targets) == 0 {
+		return nil, fmt.Errorf("%s", T("error.batchNoTargets", "Path", path))
+	}
+	for i, target := range job.Targets {
+		if target.Preset == "" && job.Preset == "" {
+			return nil, SchemaError{Field: fmt.Sprintf("targets[%d].preset", i), Message: T("error.batchNoPreset")}
+		}
+	}
+	return &job, nil
+}
+
+// resolve подставляет значения по умолчанию из задания и профиля
+func (t BatchTarget) resolve(job *BatchJob, profile *Profile) BatchTargetResult {
+	resolved := BatchTargetResult{Project: t.Project, World: t.World, Preset: t.Preset}
+	if resolved.Preset == "" {
+		resolved.Preset = job.Preset
+	}
+	if resolved.Project == "" {
+		resolved.Project = profile.ProjectName
+		if resolved.World == "" {
+			resolved.World = profile.WorldName()
+		}
+	}
+	if resolved.World == "" {
+		resolved.World = resolved.Project
+	}
+	return resolved
+}
+
+// Presets возвращает имена пресетов задания без повторов в порядке целей
+func (j *BatchJob) Presets() []string {
+	var presets []string
+	for _, target := range j.Targets {
+		preset := target.Preset
+		if preset == "" {
+			preset = j.Preset
+		}
+		presets = appendUnique(presets, preset)
+	}
+	return presets
+}
+
+// ApplyBatch применяет задание к файлу сессий профиля за один проход: все пресеты читаются
+// и проверяются до изменения файла, затем делается одна резервная копия и одна запись.
+// Слоты, которые не удалось записать, возвращаются как объединение ошибок *SlotMissingError
+// вместе с отчетом.
+func ApplyBatch(profile *Profile, job *BatchJob) (BatchResult, error) {
+	var result BatchResult
+
+	presets := make(map[string]map[string]PresetBlock)
+	for _, target := range job.Targets {
+		resolved := target.resolve(job, profile)
+		if _, loaded := presets[resolved.Preset]; loaded {
+			continue
+		}
+		blocks, err := loadPresetFamilies(resolved.Preset, profile.TemplateValues())
+		if err != nil {
+			return result, err
+		}
+		presets[resolved.Preset] = blocks
+	}
+
+	doc, err := openSessionsForApply(profile)
+	if err != nil {
+		return result, err
+	}
+	before := doc.clone()
+
+	var missing []error
+	var names []string
+	for _, target := range job.Targets {
+		resolved := target.resolve(job, profile)
+		resolved.Result = applyToWorld(doc, profile, resolved.Project, resolved.World, presets[resolved.Preset])
+		if len(resolved.Result.SlotsMissing) > 0 {
+			missing = append(missing, &SlotMissingError{World: resolved.World, Slots: resolved.Result.SlotsMissing})
+		}
+		result.Targets = append(result.Targets, resolved)
+		if !slices.Contains(names, resolved.Preset) {
+			names = append(names, resolved.Preset)
+		}
+	}
+
+	if result.Changed() {
+		result.BackupPath, err = saveAppliedDocument(profile, doc)
+		if err != nil {
+			return result, err
+		}
+		rememberApplied(profile, names...)
+		ApplyHistory.record(profile, strings.Join(names, ", "), before, doc)
+	}
+
+	Logger.Info("batch applied", "profile", profile.Name, "targets", len(result.Targets), "backup", result.BackupPath)
+	for _, target := range result.Targets {
+		Logger.Info("batch target applied",
+			"project", target.Project,
+			"world", target.World,
+			"preset", target.Preset,
+			"slots", target.Result.SlotsReplaced,
+			"inserted", target.Result.SlotsInserted,
+			"reset", target.Result.SlotsReset,
+			"missing", target.Result.SlotsMissing,
+		)
+	}
+	return result, errors.Join(missing...)
+}
@@ -0,0 +1,120 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Переменные окружения MBC_* переопределяют поля конфигурации без изменения config.json.
+// Имя переменной строится из JSON-имени поля: backup.keep -> MBC_BACKUP_KEEP.
+// Списки задаются через запятую: MBC_PRESET_SOURCES=https://...,https://...
+const envPrefix = "MBC_"
+
+// Переменная окружения с путем к config.json
+const configPathEnv = envPrefix + "CONFIG"
+
+// Поля, которые не переопределяются через окружение
+var envSkippedFields = map[string]bool{
+	"name":      true,
+	"defaults":  true, // Таблица значений по умолчанию задается только в config.json
+	"profiles":  true,
+	"variables": true, // Значения переменных шаблонов задаются в config.json или флагом --var
+	"version":   true,
+}
+
+// EnvOverrideNames возвращает имена всех переменных окружения, поддерживаемых для профиля и конфигурации
+func EnvOverrideNames() []string {
+	names := []string{configPathEnv}
+	names = append(names, envNames(reflect.TypeOf(Config{}), envPrefix)...)
+	return append(names, envNames(reflect.TypeOf(Profile{}), envPrefix)...)
+}
+
+func envNames(t reflect.Type, prefix string) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := jsonFieldName(field)
+		if tag == "" || envSkippedFields[tag] {
+			continue
+		}
+		name := prefix + strings.ToUpper(tag)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			names = append(names, envNames(fieldType, name+"_")...)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// applyEnvOverrides записывает значения переменных окружения в поля структуры по указателю target
+func applyEnvOverrides(target interface{}, prefix string) error {
+	return applyEnvToValue(reflect.ValueOf(target).Elem(), prefix)
+}
+
+func applyEnvToValue(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := jsonFieldName(field)
+		if tag == "" || envSkippedFields[tag] {
+			continue
+		}
+		name := prefix + strings.ToUpper(tag)
+		fieldValue := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnvToValue(fieldValue, name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.String:
+			fieldValue.SetString(raw)
+		case reflect.Bool:
+			value, err := strconv.ParseBool(raw)
+			if err != nil {
+				return fmt.Errorf("%s", T("error.envBool", "Name", name, "Value", raw))
+			}
+			fieldValue.SetBool(value)
+		case reflect.Int:
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				return fmt.Errorf("%s", T("error.envInt", "Name", name, "Value", raw))
+			}
+			fieldValue.SetInt(int64(value))
+		case reflect.Slice:
+			var items []string
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			fieldValue.Set(reflect.ValueOf(items))
+		}
+	}
+	return nil
+}
+
+// jsonFieldName возвращает имя поля из тега json или пустую строку для пропускаемых полей
+func jsonFieldName(field reflect.StructField) string {
+	tag := strings.Split(field.Tag.Get("json"), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	return tag
+}
@@ -3,80 +3,314 @@ package modules
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/c-bata/go-prompt"
-	"github.com/sqweek/dialog"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
-	"syscall"
 )
 
-// Структура Config для хранения путей и названия проекта
+// Имя профиля, в который переносятся настройки из старого однопрофильного config.json
+const DefaultProfileName = "default"
+
+// Структура Config хранит именованные профили и активный профиль
 type Config struct {
-	FilePath    string `json:"file_path"`    // Путь до r4LavaEditor2.sessions.ini
-	Workspace   string `json:"workspace"`    // Путь до рабочей директории (workspace)
-	ProjectName string `json:"project_name"` // Название проекта
+	Version       int       `json:"version"`             // Версия формата файла, см. config_migrations.go
+	ActiveProfile string    `json:"active_profile"`      // Имя профиля, выбранного последним
+	Profiles      []Profile `json:"profiles"`            // Профили для разных установок REDkit и проектов
+	LogLevel      string    `json:"log_level,omitempty"` // Уровень журнала: debug, info, warn, error
+	Language      string    `json:"language,omitempty"`  // Язык интерфейса: en, ru, pl; пусто - язык ОС
+
+	GitHub *GitHubSettings `json:"github,omitempty"` // Доступ к GitHub для публикации пресетов
 }
 
-var configPath = "config.json"
+// Profile хранит пути, проект и настройки одной установки REDkit
+type Profile struct {
+	Name        string `json:"name"`            // Уникальное имя профиля
+	FilePath    string `json:"file_path"`       // Путь до r4LavaEditor2.sessions.ini
+	Workspace   string `json:"workspace"`       // Путь до рабочей директории (workspace)
+	ProjectName string `json:"project_name"`    // Название проекта
+	World       string `json:"world,omitempty"` // Название мира (пусто - совпадает с названием проекта)
+
+	ProjectFilter  ProjectFilter     `json:"project_filter,omitempty"`  // Какие папки в dlc считать проектами
+	PresetSources  []string          `json:"preset_sources,omitempty"`  // Адреса GitHub API папок с пресетами
+	FoliageSources []string          `json:"foliage_sources,omitempty"` // Адреса GitHub API папок с пресетами растительности
+	Backup         BackupSettings    `json:"backup"`                    // Резервные копии файла сессий
+	Slots          SlotSettings      `json:"slots"`                     // Создание недостающих слотов и слоты сверх пресета
+	TextureCount   int               `json:"texture_count,omitempty"`   // Размер каталога текстур ландшафта для проверки пресетов (0 - не проверять)
+	Favorites      []string          `json:"favorites,omitempty"`       // Пресеты, отмеченные звездой
+	Recent         []string          `json:"recent,omitempty"`          // Недавно примененные пресеты, последний - первый
+	Variables      map[string]string `json:"variables,omitempty"`       // Значения переменных шаблонов пресетов
+
+	sessionVariables map[string]string // Значения переменных из флагов --var и ответов на запрос; не сохраняются
+}
+
+// Имя папки приложения в пользовательской папке настроек
+const appConfigFolder = "MaterialBrushChanger"
+
+// Явный путь к config.json из флага --config
+var configPathOverride string
 
-// Функция GetPaths проверяет конфигурацию, если она недействительна, предлагает пользователю выбрать путь
-func GetPaths() (string, string, string, error) {
+// SetConfigPath задает явный путь к config.json (флаг --config). Пустая строка возвращает поиск по умолчанию.
+func SetConfigPath(path string) {
+	configPathOverride = path
+}
+
+// ConfigPath возвращает путь к config.json: флаг --config, затем MBC_CONFIG,
+// затем пользовательская папка настроек (%AppData%\MaterialBrushChanger на Windows)
+func ConfigPath() string {
+	if configPathOverride != "" {
+		return configPathOverride
+	}
+	if path := os.Getenv(configPathEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "config.json"
+	}
+	return filepath.Join(dir, appConfigFolder, "config.json")
+}
+
+// legacyConfigPaths возвращает места, где config.json лежал в старых версиях (рядом с exe или в рабочей папке)
+func legacyConfigPaths() []string {
+	paths := []string{"config.json"}
+	if exe, err := os.Executable(); err == nil {
+		paths = append(paths, filepath.Join(filepath.Dir(exe), "config.json"))
+	}
+	return paths
+}
+
+// WorldName возвращает мир профиля; по умолчанию мир называется так же, как проект
+func (p *Profile) WorldName() string {
+	if p.World != "" {
+		return p.World
+	}
+	return p.ProjectName
+}
+
+// Sources возвращает источники пресетов профиля или репозиторий по умолчанию
+func (p *Profile) Sources() []string {
+	if len(p.PresetSources) > 0 {
+		return p.PresetSources
+	}
+	return []string{githubRepoAPI}
+}
+
+// Profile ищет профиль по имени
+func (c *Config) Profile(name string) *Profile {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			return &c.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// ProfileNames возвращает имена всех профилей в порядке из конфигурации
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for _, profile := range c.Profiles {
+		names = append(names, profile.Name)
+	}
+	return names
+}
+
+// profileOrCreate возвращает профиль с указанным именем, создавая его при необходимости.
+// Пустое имя означает активный профиль.
+func (c *Config) profileOrCreate(name string) *Profile {
+	if name == "" {
+		name = c.ActiveProfile
+	}
+	if name == "" {
+		name = DefaultProfileName
+	}
+	if profile := c.Profile(name); profile != nil {
+		return profile
+	}
+	c.Profiles = append(c.Profiles, Profile{Name: name, Backup: defaultBackupSettings(), Slots: defaultSlotSettings()})
+	return &c.Profiles[len(c.Profiles)-1]
+}
+
+// SourcesProfile возвращает профиль (пустое имя - MBC_ACTIVE_PROFILE или активный) с переменными
+// окружения MBC_* без проверки путей REDkit: для загрузки пресетов нужны только источники.
+// Если конфигурации или активного профиля нет, источники берутся по умолчанию.
+func SourcesProfile(profileName string) (*Profile, error) {
+	config, err := LoadConfig()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %v", T("error.configLoad"), err)
+	}
+	if config == nil {
+		config = &Config{}
+	}
+	effective := Profile{}
+	if profileName != "" {
+		profile := config.Profile(profileName)
+		if profile == nil {
+			return nil, fmt.Errorf("%s", T("error.profileNotFound", "Name", profileName))
+		}
+		effective = *profile
+	} else {
+		// Как в GetPaths: MBC_ACTIVE_PROFILE важнее активного профиля; профиль, которого еще нет,
+		// GetPaths создал бы с источниками по умолчанию
+		name := os.Getenv(envPrefix + "ACTIVE_PROFILE")
+		if name == "" {
+			name = config.ActiveProfile
+		}
+		if profile := config.Profile(name); profile != nil {
+			effective = *profile
+		}
+	}
+	if err := applyEnvOverrides(&effective, envPrefix); err != nil {
+		return nil, err
+	}
+	return &effective, nil
+}
+
+// Функция GetPaths проверяет профиль (пустое имя - активный профиль), и если пути в нем недействительны,
+// предлагает пользователю выбрать их через переданный Prompter (диалоги GUI или консоль).
+// Переменные окружения MBC_* действуют только на возвращаемый профиль и в config.json не сохраняются.
+func GetPaths(profileName string, p Prompter) (*Profile, error) {
 	// Пытаемся загрузить конфигурацию
 	config, err := LoadConfig()
-	if err != nil || config == nil {
+	if err != nil && !os.IsNotExist(err) {
+		// Поврежденный файл не перезаписываем молча, иначе пользователь потеряет профили
+		return nil, err
+	}
+	if config == nil {
 		// Если конфигурация не загружена, создаем новую
 		config = &Config{}
 	}
 
+	if profileName == "" {
+		profileName = os.Getenv(envPrefix + "ACTIVE_PROFILE")
+	}
+	profile := config.profileOrCreate(profileName)
+
+	effective := *profile
+	if err := applyEnvOverrides(&effective, envPrefix); err != nil {
+		return nil, err
+	}
+	overridden := effective
+
 	// Проверяем и получаем путь к r4LavaEditor2.sessions.ini
-	fullPath, err := getRedkitPath(config)
+	fullPath, err := getRedkitPath(&effective, p)
 	if err != nil {
-		return "", "", "", err
+		return nil, err
 	}
 
 	// Проверяем и получаем путь к рабочему пространству и название проекта
-	workspacePath, projectName, err := getWorkspaceAndProjectName(config)
+	workspacePath, projectName, err := getWorkspaceAndProjectName(&effective, p)
 	if err != nil {
-		return "", "", "", err
+		return nil, err
 	}
 
+	// В конфигурацию попадает только то, что пользователь выбрал в диалогах
+	if effective.FilePath != overridden.FilePath {
+		profile.FilePath = effective.FilePath
+	}
+	if effective.Workspace != overridden.Workspace {
+		profile.Workspace = effective.Workspace
+	}
+	if effective.ProjectName != overridden.ProjectName {
+		profile.ProjectName = effective.ProjectName
+	}
+	config.ActiveProfile = profile.Name
+
 	// Сохраняем обновленную конфигурацию
 	err = SaveConfig(config)
 	if err != nil {
-		return "", "", "", fmt.Errorf("ошибка сохранения конфигурации: %v", err)
+		return nil, fmt.Errorf("%s: %v", T("error.configSave"), err)
 	}
 
-	fmt.Printf("Paths saved to config.json: REDkit Path: %s, Workspace Path: %s, Project Name: %s\n", fullPath, workspacePath, projectName)
-	return fullPath, workspacePath, projectName, nil
+	Logger.Info("paths saved", "config", ConfigPath(), "profile", profile.Name, "sessions", fullPath, "workspace", workspacePath, "project", projectName)
+	return &effective, nil
 }
 
-// Функция для загрузки конфигурации
+// Функция для загрузки конфигурации. Файлы старых версий мигрируются до текущей версии,
+// затем результат проверяется по JSON-схеме schemas/config.schema.json.
 func LoadConfig() (*Config, error) {
-	file, err := ioutil.ReadFile(configPath)
+	file, err := ioutil.ReadFile(ConfigPath())
+	if os.IsNotExist(err) && configPathOverride == "" {
+		file, err = readLegacyConfig()
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	var config Config
-	err = json.Unmarshal(file, &config)
+	return parseConfig(file)
+}
+
+// readLegacyConfig ищет config.json на старых местах, чтобы перенести его в папку настроек
+func readLegacyConfig() ([]byte, error) {
+	for _, path := range legacyConfigPaths() {
+		file, err := ioutil.ReadFile(path)
+		if err == nil {
+			Logger.Info("using legacy config", "path", path, "target", ConfigPath())
+			return file, nil
+		}
+	}
+	return nil, &os.PathError{Op: "open", Path: ConfigPath(), Err: os.ErrNotExist}
+}
+
+// parseConfig мигрирует, проверяет и декодирует содержимое config.json
+func parseConfig(file []byte) (*Config, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(file, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.configParse", "Path", ConfigPath()), err)
+	}
+
+	migrated, err := migrateConfig(raw)
+	if err != nil {
+		return nil, err
+	}
+	if migrated {
+		Logger.Info("config migrated", "path", ConfigPath(), "version", currentConfigVersion)
+	}
+
+	if err := validateAgainstSchema("config.schema.json", raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", T("error.configInvalid", "Path", ConfigPath()), err)
+	}
+
+	normalized, err := json.Marshal(raw)
 	if err != nil {
 		return nil, err
 	}
+	var config Config
+	if err := json.Unmarshal(normalized, &config); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
 
+// SetActiveProfile делает профиль активным и сохраняет конфигурацию
+func SetActiveProfile(name string) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("%s: %v", T("error.configLoad"), err)
+	}
+	if config.Profile(name) == nil {
+		return fmt.Errorf("%s", T("error.profileNotFound", "Name", name))
+	}
+	config.ActiveProfile = name
+	return SaveConfig(config)
+}
+
 // Функция для сохранения конфигурации
 func SaveConfig(config *Config) error {
+	config.Version = currentConfigVersion
+	if config.Profiles == nil {
+		config.Profiles = []Profile{}
+	}
 	file, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(configPath, file, 0644)
+	if err := os.MkdirAll(filepath.Dir(ConfigPath()), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ConfigPath(), file, 0644)
 }
 
 // Функция проверки наличия файла или директории
@@ -85,49 +319,62 @@ func FileExists(path string) bool {
 	return err == nil
 }
 
-func getRedkitPath(config *Config) (string, error) {
+func getRedkitPath(config *Profile, p Prompter) (string, error) {
 	if config.FilePath != "" && FileExists(config.FilePath) {
-		fmt.Printf("Last selected file exists: %s\n", config.FilePath)
+		Logger.Debug("using sessions file from profile", "path", config.FilePath)
 		return config.FilePath, nil
 	}
 
-	fmt.Println("No valid configuration found for REDkit.")
-	fmt.Println("Please select the folder path to The Witcher 3 REDkit folder")
+	Logger.Info("no valid REDkit path in profile, searching installations")
+
+	// Сначала ищем установки автоматически и предлагаем выбрать одну из них
+	if installs := DiscoverRedkit(DefaultDiscoveryOptions()); len(installs) > 0 {
+		sessionsPath, err := p.ChooseRedkit(installs)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", T("error.chooseRedkit"), err)
+		}
+		if sessionsPath != "" {
+			if !FileExists(sessionsPath) {
+				return "", fmt.Errorf("%w: %s", ErrSessionNotFound, T("error.sessionsNotCreated", "Path", sessionsPath))
+			}
+			config.FilePath = sessionsPath
+			return sessionsPath, nil
+		}
+	}
 
 	// Открываем диалог для выбора директории
-	folder, err := dialog.Directory().Title("Select The Witcher 3 REDkit folder").Browse()
+	folder, err := p.ChooseDirectory(T("prompt.redkitFolder"))
 	if err != nil {
-		return "", fmt.Errorf("ошибка выбора директории: %v", err)
+		return "", fmt.Errorf("%s: %v", T("error.chooseDirectory"), err)
 	}
 
 	var fullPath string
 
 	if strings.HasSuffix(strings.ToLower(folder), "bin") {
-		fmt.Println()
 		// Если путь содержит "bin", добавляем r4LavaEditor2.sessions.ini
 		fullPath = filepath.Join(folder, "r4LavaEditor2.sessions.ini")
-		fmt.Println("Путь, сформированный в ветке bin:", fullPath) // Отладочная информация
+		Logger.Debug("sessions path resolved from bin folder", "path", fullPath)
 
 	} else // Проверка, содержит ли путь подпапку "The Witcher 3 REDkit"
 	if strings.Contains(strings.ToLower(folder), "the witcher 3 redkit") {
 		// Если выбран путь к The Witcher 3 REDkit, добавляем bin и r4LavaEditor2.sessions.ini
 		fullPath = filepath.Join(folder, "bin", "r4LavaEditor2.sessions.ini")
-		fmt.Println("Путь, сформированный в ветке The Witcher 3 REDkit:", fullPath) // Отладочная информация
+		Logger.Debug("sessions path resolved from REDkit folder", "path", fullPath)
 	} else {
 		// Если путь не содержит ни REDkit, ни bin, ищем "The Witcher 3 REDkit\bin" внутри
 		redkitBinPath := filepath.Join(folder, "The Witcher 3 REDkit", "bin", "r4LavaEditor2.sessions.ini")
 		if FileExists(redkitBinPath) {
 			fullPath = redkitBinPath
-			fmt.Println("Путь, сформированный в ветке The Witcher 3 REDkit\\bin:", fullPath) // Отладочная информация
+			Logger.Debug("sessions path resolved from parent folder", "path", fullPath)
 		} else {
 			// Если путь не содержит ни REDkit, ни bin, и не найден "The Witcher 3 REDkit\bin" внутри, возвращаем ошибку
-			return "", fmt.Errorf("ошибка: выбранная директория не содержит ни 'The Witcher 3 REDkit', ни 'bin', ни 'The Witcher 3 REDkit\\bin'")
+			return "", fmt.Errorf("%s", T("error.notRedkitFolder"))
 		}
 	}
 
 	// Проверяем существование файла
 	if !FileExists(fullPath) {
-		return "", fmt.Errorf("ошибка: файл r4LavaEditor2.sessions.ini не найден по пути: %s", fullPath)
+		return "", fmt.Errorf("%w: %s", ErrSessionNotFound, fullPath)
 	}
 
 	// Сохраняем путь в конфигурацию
@@ -135,16 +382,15 @@ func getRedkitPath(config *Config) (string, error) {
 	return fullPath, nil
 }
 
-func getWorkspaceAndProjectName(config *Config) (string, string, error) {
+func getWorkspaceAndProjectName(config *Profile, p Prompter) (string, string, error) {
 	if config.Workspace != "" && FileExists(config.Workspace) && config.ProjectName != "" {
-		fmt.Printf("Last selected workspace and project exist: %s, %s\n", config.Workspace, config.ProjectName)
+		Logger.Debug("using workspace and project from profile", "workspace", config.Workspace, "project", config.ProjectName)
 		return config.Workspace, config.ProjectName, nil // Возвращаем данные из конфигурации
 	}
 
-	fmt.Println("Please select the workspace folder (workspace).")
-	workspaceFolder, err := dialog.Directory().Title("Select Workspace Folder").Browse()
+	workspaceFolder, err := p.ChooseDirectory(T("prompt.workspaceFolder"))
 	if err != nil {
-		return "", "", fmt.Errorf("ошибка выбора директории workspace: %v", err)
+		return "", "", fmt.Errorf("%s: %v", T("error.chooseWorkspace"), err)
 	}
 
 	// Проверяем, выбрана ли папка "workspace" или папка выше
@@ -152,7 +398,7 @@ func getWorkspaceAndProjectName(config *Config) (string, string, error) {
 		// Если выбрана папка выше, ищем "workspace" внутри нее
 		workspacePath := filepath.Join(workspaceFolder, "workspace")
 		if !FileExists(workspacePath) {
-			return "", "", fmt.Errorf("ошибка: папка 'workspace' не найдена внутри выбранной директории")
+			return "", "", fmt.Errorf("%s", T("error.workspaceNotFound"))
 		} else {
 			workspaceFolder = workspacePath
 		}
@@ -162,111 +408,77 @@ func getWorkspaceAndProjectName(config *Config) (string, string, error) {
 
 	dlcPath := filepath.Join(config.Workspace, "dlc")
 	if !FileExists(dlcPath) {
-		return "", "", fmt.Errorf("ошибка: папка 'dlc' не найдена в workspace")
+		return "", "", fmt.Errorf("%s", T("error.dlcNotFound"))
 	}
 
-	projectName, err := getProjectNameWithFilter(dlcPath)
+	projectName, err := getProjectNameWithFilter(dlcPath, config.ProjectFilter, p)
 	if err != nil {
-		return "", "", fmt.Errorf("ошибка получения названия проекта: %v", err)
-	}
-
-	// Закрытие консоли после завершения работы с ней
-	if err := closeConsole(); err != nil {
-		fmt.Printf("Ошибка при закрытии консоли: %v\n", err)
+		return "", "", fmt.Errorf("%s: %v", T("error.projectName"), err)
 	}
 
 	config.ProjectName = projectName
 	return config.Workspace, projectName, nil
 }
 
-var kernel32 = syscall.NewLazyDLL("kernel32.dll")
-var procAllocConsole = kernel32.NewProc("AllocConsole")
-var procFreeConsole = kernel32.NewProc("FreeConsole")
-
-func closeConsole() error {
-	r, _, err := procFreeConsole.Call()
-	if r == 0 {
-		return fmt.Errorf("не удалось закрыть консоль: %v", err)
-	}
-	return nil
-}
-
-func showConsole() (*os.File, error) {
-	r, _, err := procAllocConsole.Call()
-	if r == 0 {
-		return nil, fmt.Errorf("не удалось открыть консоль: %v", err)
-	}
-
-	// Получаем дескриптор новой консоли
-	consoleHandle, err := syscall.GetStdHandle(syscall.STD_OUTPUT_HANDLE)
+func getProjectNameWithFilter(dlcPath string, filter ProjectFilter, p Prompter) (string, error) {
+	projects, err := ListProjects(dlcPath, filter)
 	if err != nil {
-		return nil, fmt.Errorf("не удалось получить дескриптор консоли: %v", err)
+		return "", err
 	}
 
-	// Открываем файл, связанный с дескриптором консоли
-	consoleFile := os.NewFile(uintptr(consoleHandle), "/dev/stdout")
-	if consoleFile == nil {
-		return nil, fmt.Errorf("не удалось открыть файл консоли")
+	if len(projects) == 0 {
+		return "", fmt.Errorf("%s", T("error.noProjects"))
+	} else if len(projects) == 1 {
+		return projects[0].Name, nil
 	}
 
-	// Перенаправляем stdout в новую консоль
-	os.Stdout = consoleFile
-
-	return consoleFile, nil
+	// Если найдено несколько папок, просим пользователя выбрать проект
+	return p.ChooseProject(projects)
 }
 
-func getProjectNameWithFilter(dlcPath string) (string, error) {
-	entries, err := os.ReadDir(dlcPath)
+// ChangeProject заново предлагает выбрать проект из workspace профиля и сохраняет выбор в конфигурацию.
+// Используется кнопкой смены проекта без перезапуска приложения. Возвращает профиль с новым проектом
+// и переменными окружения MBC_*, как GetPaths; выбранный проект важнее MBC_PROJECT_NAME.
+func ChangeProject(profileName string, p Prompter) (*Profile, error) {
+	config, err := LoadConfig()
 	if err != nil {
-		return "", fmt.Errorf("ошибка чтения папки 'dlc': %v", err)
+		return nil, fmt.Errorf("%s: %v", T("error.configLoad"), err)
 	}
-
-	var pafFolders []string
-	for _, entry := range entries {
-		if entry.IsDir() && strings.HasPrefix(entry.Name(), "paf") {
-			pafFolders = append(pafFolders, entry.Name())
-		}
+	profile := config.Profile(profileName)
+	if profile == nil {
+		return nil, fmt.Errorf("%s", T("error.profileNotFound", "Name", profileName))
 	}
 
-	if len(pafFolders) == 0 {
-		return "", fmt.Errorf("ошибка: папки, начинающиеся на 'paf', не найдены в 'dlc'")
-	} else if len(pafFolders) == 1 {
-		return pafFolders[0], nil
-	} else if len(pafFolders) > 1 {
-		// Открываем консольное окно для взаимодействия
-		consoleFile, err := showConsole()
-		if err != nil {
-			return "", err
-		}
-		defer func(consoleFile *os.File) {
-			err := consoleFile.Close()
-			if err != nil {
-
-			}
-		}(consoleFile) // Закрываем файл консоли в конце функции
-
-		// Если найдено несколько папок, используем консольный ввод для выбора
-		fmt.Println("Найдено несколько проектов:")
-		for i, folder := range pafFolders {
-			fmt.Printf("%d. %s\n", i+1, folder)
-		}
+	effective := *profile
+	if err := applyEnvOverrides(&effective, envPrefix); err != nil {
+		return nil, err
+	}
 
-		for {
-			input := prompt.Input("Выберите номер проекта: ", completer)
-			choice, err := strconv.Atoi(input)
-			if err != nil || choice < 1 || choice > len(pafFolders) {
-				fmt.Println("Ошибка: неверный номер проекта")
-				continue
-			}
+	dlcPath := filepath.Join(effective.Workspace, "dlc")
+	projects, err := ListProjects(dlcPath, effective.ProjectFilter)
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("%s", T("error.noProjects"))
+	}
 
-			return pafFolders[choice-1], nil
-		}
+	projectName, err := p.ChooseProject(projects)
+	if err != nil {
+		return nil, err
 	}
 
-	return "", fmt.Errorf("неожиданная ошибка")
-}
+	// Мир прежнего проекта к новому проекту не относится
+	profile.ProjectName = projectName
+	profile.World = ""
+	if err := SaveConfig(config); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.configSave"), err)
+	}
 
-func completer(d prompt.Document) []prompt.Suggest {
-	// Здесь можно добавить автодополнение, если нужно
-	return []prompt.Suggest{}
+	effective = *profile
+	if err := applyEnvOverrides(&effective, envPrefix); err != nil {
+		return nil, err
+	}
+	effective.ProjectName = projectName
+	return &effective, nil
 }
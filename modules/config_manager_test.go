@@ -0,0 +1,50 @@
+package modules
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSourcesProfile(t *testing.T) {
+	SetConfigPath(filepath.Join(t.TempDir(), "config.json"))
+	t.Cleanup(func() { SetConfigPath("") })
+	config := &Config{
+		ActiveProfile: "main",
+		Profiles: []Profile{
+			{Name: "main", PresetSources: []string{"https://example.test/main"}},
+			{Name: "dlc2", PresetSources: []string{"https://example.test/dlc2"}},
+		},
+	}
+	if err := SaveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		profile string // Значение --profile
+		env     string // Значение MBC_ACTIVE_PROFILE
+		sources []string
+	}{
+		{"активный профиль", "", "", []string{"https://example.test/main"}},
+		{"профиль из окружения", "", "dlc2", []string{"https://example.test/dlc2"}},
+		{"флаг важнее окружения", "main", "dlc2", []string{"https://example.test/main"}},
+		{"нового профиля из окружения еще нет", "", "dlc3", []string{githubRepoAPI}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envPrefix+"ACTIVE_PROFILE", tt.env)
+			profile, err := SourcesProfile(tt.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := profile.Sources(); !slices.Equal(got, tt.sources) {
+				t.Errorf("источники %v, ожидалось %v", got, tt.sources)
+			}
+		})
+	}
+
+	if _, err := SourcesProfile("missing"); err == nil || err.Error() != T("error.profileNotFound", "Name", "missing") {
+		t.Errorf("ошибка %v, ожидалось сообщение о ненайденном профиле", err)
+	}
+}
@@ -0,0 +1,118 @@
+package modules
+
+import (
+	"fmt"
+)
+
+// Текущая версия формата config.json.
+// 1 - один набор путей без профилей, 2 - именованные профили, 3 - настройки слотов в профилях,
+// 4 - нормализация ключей слотов.
+const currentConfigVersion = 4
+
+// Миграции config.json: ключ - версия, из которой миграция переводит файл в следующую
+var configMigrations = map[int]func(raw map[string]interface{}) error{
+	1: migrateConfigV1ToV2,
+	2: migrateConfigV2ToV3,
+	3: migrateConfigV3ToV4,
+}
+
+// detectConfigVersion определяет версию файла; у старых файлов поля version нет
+func detectConfigVersion(raw map[string]interface{}) (int, error) {
+	if value, ok := raw["version"]; ok {
+		number, ok := value.(float64)
+		if !ok || number < 1 {
+			return 0, SchemaError{Field: "version", Message: T("schema.version", "Value", value)}
+		}
+		return int(number), nil
+	}
+	if _, ok := raw["profiles"]; ok {
+		return 2, nil
+	}
+	return 1, nil
+}
+
+// migrateConfig последовательно применяет миграции до текущей версии.
+// Возвращает true, если документ был изменен.
+func migrateConfig(raw map[string]interface{}) (bool, error) {
+	version, err := detectConfigVersion(raw)
+	if err != nil {
+		return false, err
+	}
+	if version > currentConfigVersion {
+		return false, fmt.Errorf("%s", T("error.configTooNew", "Version", version, "Max", currentConfigVersion))
+	}
+
+	migrated := false
+	for ; version < currentConfigVersion; version++ {
+		migration, ok := configMigrations[version]
+		if !ok {
+			return false, fmt.Errorf("%s", T("error.configNoMigration", "Version", version))
+		}
+		if err := migration(raw); err != nil {
+			return false, fmt.Errorf("%s: %v", T("error.configMigration", "Version", version), err)
+		}
+		migrated = true
+	}
+	if _, ok := raw["version"]; !ok {
+		migrated = true
+	}
+	raw["version"] = float64(currentConfigVersion)
+	return migrated, nil
+}
+
+// migrateConfigV1ToV2 переносит поля однопрофильного формата в профиль "default"
+func migrateConfigV1ToV2(raw map[string]interface{}) error {
+	profile := map[string]interface{}{
+		"name":   DefaultProfileName,
+		"backup": map[string]interface{}{"enabled": true, "keep": float64(20)},
+	}
+	hasPaths := false
+	for _, key := range []string{"file_path", "workspace", "project_name", "project_filter"} {
+		if value, ok := raw[key]; ok {
+			profile[key] = value
+			delete(raw, key)
+			hasPaths = true
+		}
+	}
+
+	raw["profiles"] = []interface{}{}
+	if hasPaths {
+		raw["profiles"] = []interface{}{profile}
+		raw["active_profile"] = DefaultProfileName
+	}
+	return nil
+}
+
+// migrateConfigV2ToV3 включает создание недостающих слотов в существующих профилях
+func migrateConfigV2ToV3(raw map[string]interface{}) error {
+	profiles, _ := raw["profiles"].([]interface{})
+	for _, item := range profiles {
+		profile, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, exists := profile["slots"]; !exists {
+			profile["slots"] = map[string]interface{}{"insert_missing": true, "extra": ExtraSlotsKeep}
+		}
+	}
+	return nil
+}
+
+// migrateConfigV3ToV4 включает дополнение недостающих ключей и сохранение неизвестных ключей слотов
+func migrateConfigV3ToV4(raw map[string]interface{}) error {
+	profiles, _ := raw["profiles"].([]interface{})
+	for _, item := range profiles {
+		profile, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		slots, ok := profile["slots"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, exists := slots["keys"]; !exists {
+			slots["keys"] = map[string]interface{}{"fill_defaults": true, "unknown": UnknownKeysKeep}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package modules
+
+import (
+	"fmt"
+	"os"
+)
+
+// На Linux и macOS приложение запускается из терминала (или под Wine/Proton
+// через обертку), поэтому отдельную консоль выделять не нужно.
+func closeConsole() error {
+	return nil
+}
+
+// showConsole возвращает управляющий терминал процесса, если он есть
+func showConsole() (*os.File, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.terminalOpen"), err)
+	}
+	return tty, nil
+}
@@ -0,0 +1,66 @@
+//go:build windows
+
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+var kernel32 = syscall.NewLazyDLL("kernel32.dll")
+var procAttachConsole = kernel32.NewProc("AttachConsole")
+var procAllocConsole = kernel32.NewProc("AllocConsole")
+var procFreeConsole = kernel32.NewProc("FreeConsole")
+
+// attachParentProcess - ATTACH_PARENT_PROCESS: консоль процесса, который запустил программу
+const attachParentProcess = uintptr(^uint32(0))
+
+var (
+	consoleAllocated bool     // Консоль выделена самой программой, и ее нужно освободить
+	previousStdout   *os.File // os.Stdout до перенаправления в консоль
+)
+
+func closeConsole() error {
+	if previousStdout != nil {
+		os.Stdout = previousStdout
+		previousStdout = nil
+	}
+	if !consoleAllocated {
+		return nil
+	}
+	consoleAllocated = false
+	r, _, err := procFreeConsole.Call()
+	if r == 0 {
+		return fmt.Errorf("%s: %v", T("error.consoleFree"), err)
+	}
+	return nil
+}
+
+// showConsole подключается к консоли, из которой запущена программа, и только если ее нет,
+// выделяет новую. ERROR_ACCESS_DENIED от AttachConsole значит, что консоль у процесса уже есть.
+func showConsole() (*os.File, error) {
+	r, _, err := procAttachConsole.Call(attachParentProcess)
+	if r == 0 && !errors.Is(err, syscall.ERROR_ACCESS_DENIED) {
+		r, _, err = procAllocConsole.Call()
+		if r == 0 {
+			return nil, fmt.Errorf("%s: %v", T("error.consoleAlloc"), err)
+		}
+		consoleAllocated = true
+	}
+
+	// Открываем вывод текущей консоли отдельным дескриптором, чтобы его можно было закрыть,
+	// не трогая стандартные дескрипторы процесса
+	consoleFile, err := os.OpenFile("CONOUT$", os.O_RDWR, 0)
+	if err != nil {
+		closeConsole()
+		return nil, fmt.Errorf("%s: %v", T("error.consoleFile"), err)
+	}
+
+	// Перенаправляем stdout в консоль до closeConsole
+	previousStdout = os.Stdout
+	os.Stdout = consoleFile
+
+	return consoleFile, nil
+}
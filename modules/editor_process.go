@@ -0,0 +1,20 @@
+package modules
+
+import "strings"
+
+// Имена процессов редактора REDkit, который держит файл сессий и перезаписывает его при выходе
+var editorProcessNames = []string{"r4LavaEditor2.exe"}
+
+// isEditorProcess проверяет имя процесса или командную строку без учета регистра
+func isEditorProcess(name string) bool {
+	lower := strings.ToLower(name)
+	for _, editor := range editorProcessNames {
+		if strings.Contains(lower, strings.ToLower(editor)) {
+			return true
+		}
+	}
+	return false
+}
+
+// editorRunning - проверка запущенного редактора; переменная, чтобы ее можно было подменить в тестах
+var editorRunning = isEditorRunning
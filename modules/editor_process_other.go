@@ -0,0 +1,27 @@
+//go:build !windows
+
+package modules
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// isEditorRunning ищет редактор среди процессов в /proc (под Wine/Proton имя exe есть в командной строке).
+// На системах без /proc считаем, что редактор не запущен.
+func isEditorRunning() (bool, error) {
+	cmdlines, err := filepath.Glob("/proc/[0-9]*/cmdline")
+	if err != nil {
+		return false, err
+	}
+	for _, path := range cmdlines {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if isEditorProcess(string(data)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
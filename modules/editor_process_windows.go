@@ -0,0 +1,31 @@
+//go:build windows
+
+package modules
+
+import (
+	"encoding/csv"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// isEditorRunning ищет процесс редактора в выводе tasklist
+func isEditorRunning() (bool, error) {
+	cmd := exec.Command("tasklist", "/FO", "CSV", "/NH")
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	output, err := cmd.Output()
+	if err != nil {
+		return false, err
+	}
+
+	records, err := csv.NewReader(strings.NewReader(string(output))).ReadAll()
+	if err != nil {
+		return false, err
+	}
+	for _, record := range records {
+		if len(record) > 0 && isEditorProcess(record[0]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
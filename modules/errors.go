@@ -0,0 +1,238 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ошибки применения пресетов; проверяются через errors.Is
+var (
+	ErrSessionNotFound error = &localizedError{id: "error.sessionNotFound"}
+	ErrSlotMissing     error = &localizedError{id: "error.slotMissing"}
+	ErrEncoding        error = &localizedError{id: "error.encoding"}
+	ErrEditorRunning   error = &localizedError{id: "error.editorRunning"}
+	ErrInvalidValue    error = &localizedError{id: "error.invalidValue"}
+	ErrMergeConflict   error = &localizedError{id: "error.mergeConflict"}
+	ErrMissingVariable error = &localizedError{id: "error.missingVariable"}
+	ErrInheritance     error = &localizedError{id: "error.inheritance"}
+	ErrGitHub          error = &localizedError{id: "error.github"}
+)
+
+// localizedError - ошибка, текст которой берется из каталога сообщений на текущем языке
+type localizedError struct {
+	id string
+}
+
+func (e *localizedError) Error() string {
+	return T(e.id)
+}
+
+// SlotMissingError перечисляет слоты пресета, для которых в файле сессий нет секций.
+// errors.Is(err, ErrSlotMissing) возвращает true.
+type SlotMissingError struct {
+	World string
+	Slots []string
+}
+
+func (e *SlotMissingError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrSlotMissing, T("error.slotMissingDetails", "World", e.World, "Slots", strings.Join(e.Slots, ", ")))
+}
+
+func (e *SlotMissingError) Unwrap() error {
+	return ErrSlotMissing
+}
+
+// EncodingError описывает строку, которую не удалось прочитать или записать в кодировке Windows-1251.
+// errors.Is(err, ErrEncoding) возвращает true.
+type EncodingError struct {
+	Path   string
+	Line   int // Номер строки или 0, если ошибка относится ко всему файлу
+	Reason string
+}
+
+func (e *EncodingError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s: %s", ErrEncoding, T("error.encodingLine", "Path", e.Path, "Line", e.Line, "Reason", e.Reason))
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrEncoding, e.Path, e.Reason)
+}
+
+func (e *EncodingError) Unwrap() error {
+	return ErrEncoding
+}
+
+// ValueProblem - недопустимое значение ключа в блоке пресета
+type ValueProblem struct {
+	Section string // Заголовок блока
+	Key     string
+	Value   string
+	Reason  string
+}
+
+// String возвращает описание проблемы: секция, ключ со значением (если есть) и причина
+func (p ValueProblem) String() string {
+	if p.Key == "" {
+		return fmt.Sprintf("%s: %s", p.Section, p.Reason)
+	}
+	return fmt.Sprintf("%s %s=%s: %s", p.Section, p.Key, p.Value, p.Reason)
+}
+
+// InvalidPresetError перечисляет недопустимые значения пресета.
+// errors.Is(err, ErrInvalidValue) возвращает true.
+type InvalidPresetError struct {
+	Path     string
+	Problems []ValueProblem
+}
+
+func (e *InvalidPresetError) Error() string {
+	details := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		details[i] = problem.String()
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrInvalidValue, e.Path, strings.Join(details, "; "))
+}
+
+func (e *InvalidPresetError) Unwrap() error {
+	return ErrInvalidValue
+}
+
+// MergeConflictError перечисляет ключи, которые после предпросмотра изменил и пресет, и кто-то другой.
+// errors.Is(err, ErrMergeConflict) возвращает true.
+type MergeConflictError struct {
+	Path      string
+	Conflicts []MergeConflict
+}
+
+func (e *MergeConflictError) Error() string {
+	details := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		details[i] = conflict.ID()
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrMergeConflict, e.Path, strings.Join(details, "; "))
+}
+
+func (e *MergeConflictError) Unwrap() error {
+	return ErrMergeConflict
+}
+
+// MissingVariablesError перечисляет переменные шаблона пресета, значений которых нет ни в профиле,
+// ни во флагах, ни в объявлениях пресета. errors.Is(err, ErrMissingVariable) возвращает true.
+type MissingVariablesError struct {
+	Path  string
+	Names []string
+}
+
+func (e *MissingVariablesError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrMissingVariable, e.Path, strings.Join(e.Names, ", "))
+}
+
+func (e *MissingVariablesError) Unwrap() error {
+	return ErrMissingVariable
+}
+
+// InheritanceError - цепочку наследования пресета не удалось построить: родителя нет
+// ни рядом с пресетом, ни в локальных папках, или цепочка замыкается на себя.
+// errors.Is(err, ErrInheritance) возвращает true.
+type InheritanceError struct {
+	Path    string
+	Chain   []string // Пресеты цепочки до места ошибки; при замыкании последний повторяет один из предыдущих
+	Missing string   // Имя ненайденного родителя; пусто - цепочка замкнута
+}
+
+func (e *InheritanceError) Error() string {
+	chain := strings.Join(e.Chain, " → ")
+	if e.Missing != "" {
+		return fmt.Sprintf("%s: %s: %s", ErrInheritance, e.Path, T("error.parentNotFound", "Name", e.Missing, "Chain", chain))
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrInheritance, e.Path, T("error.inheritanceCycle", "Chain", chain))
+}
+
+func (e *InheritanceError) Unwrap() error {
+	return ErrInheritance
+}
+
+// GitHubError - GitHub отклонил запрос публикации; Message - объяснение из ответа API.
+// errors.Is(err, ErrGitHub) возвращает true.
+type GitHubError struct {
+	Method  string
+	Path    string
+	Status  int
+	Message string
+}
+
+func (e *GitHubError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrGitHub, T("error.githubStatus", "Method", e.Method, "Path", e.Path, "Status", e.Status, "Message", e.Message))
+}
+
+func (e *GitHubError) Unwrap() error {
+	return ErrGitHub
+}
+
+// ApplyResult - итог применения пресета к файлу сессий
+type ApplyResult struct {
+	SlotsReplaced []string // Номера слотов, секции которых заменены
+	SlotsInserted []string // Номера слотов, секции которых созданы заново
+	SlotsReset    []string // Номера слотов мира сверх пресета, которые сброшены или выключены
+	ToolsReplaced []string // Секции настроек инструментов TerrainEdit, которые заменены
+	ToolsInserted []string // Секции настроек инструментов, созданные заново
+	SlotsMissing  []string // Слоты и инструменты пресета, секций которых нет в файле
+	UnknownKeys   []string // Неизвестные ключи в виде "секция:ключ" (только в режиме warn)
+	BackupPath    string   // Резервная копия, сделанная перед записью (пусто, если не делалась)
+}
+
+// reportUnknownKeys запоминает неизвестные ключи секции, если профиль просит о них предупреждать
+func (r *ApplyResult) reportUnknownKeys(settings KeySettings, label string, keys []string) {
+	if settings.Unknown != UnknownKeysWarn {
+		return
+	}
+	for _, key := range keys {
+		Logger.Warn("unknown section key", "section", label, "key", key)
+		r.UnknownKeys = append(r.UnknownKeys, label+":"+key)
+	}
+}
+
+// Changed сообщает, был ли изменен файл сессий
+func (r ApplyResult) Changed() bool {
+	return len(r.SlotsReplaced)+len(r.SlotsInserted)+len(r.SlotsReset)+len(r.ToolsReplaced)+len(r.ToolsInserted) > 0
+}
+
+// addSection записывает замененную или созданную секцию в список ее вида
+func (r *ApplyResult) addSection(ref sectionRef, inserted bool) {
+	switch {
+	case ref.Kind == materialPairKind && inserted:
+		r.SlotsInserted = append(r.SlotsInserted, ref.label())
+	case ref.Kind == materialPairKind:
+		r.SlotsReplaced = append(r.SlotsReplaced, ref.label())
+	case inserted:
+		r.ToolsInserted = append(r.ToolsInserted, ref.label())
+	default:
+		r.ToolsReplaced = append(r.ToolsReplaced, ref.label())
+	}
+}
+
+// Summary возвращает строки отчета о примененном пресете на текущем языке
+func (r ApplyResult) Summary() []string {
+	var lines []string
+	if len(r.SlotsReplaced) > 0 {
+		lines = append(lines, T("result.slotsReplaced", "Slots", strings.Join(r.SlotsReplaced, ", ")))
+	}
+	if len(r.SlotsInserted) > 0 {
+		lines = append(lines, T("result.slotsInserted", "Slots", strings.Join(r.SlotsInserted, ", ")))
+	}
+	if len(r.SlotsReset) > 0 {
+		lines = append(lines, T("result.slotsReset", "Slots", strings.Join(r.SlotsReset, ", ")))
+	}
+	if len(r.ToolsReplaced) > 0 {
+		lines = append(lines, T("result.toolsReplaced", "Tools", strings.Join(r.ToolsReplaced, ", ")))
+	}
+	if len(r.ToolsInserted) > 0 {
+		lines = append(lines, T("result.toolsInserted", "Tools", strings.Join(r.ToolsInserted, ", ")))
+	}
+	if len(r.UnknownKeys) > 0 {
+		lines = append(lines, T("result.unknownKeys", "Keys", strings.Join(r.UnknownKeys, ", ")))
+	}
+	if r.BackupPath != "" {
+		lines = append(lines, T("result.backup", "Path", r.BackupPath))
+	}
+	return lines
+}
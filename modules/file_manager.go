@@ -3,9 +3,8 @@ package modules
 import (
 	"bufio"
 	"fmt"
-	"io"
 	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
 
 	"golang.org/x/text/encoding/charmap"
@@ -15,169 +14,201 @@ import (
 // Структура для хранения блока пресета
 type PresetBlock struct {
 	Header string
+	Keys   []string // Порядок ключей, как в файле пресета
 	Values map[string]string
 }
 
-// Массив с заданным порядком ключей
-var keysOrder = []string{
-	"VerticalMask",
-	"VerticalUVMult",
-	"VerticalUVScaleMask",
-	"HeightLowLimit",
-	"Probability",
-	"PresetEnabled",
-	"HighLimitMask",
-	"HorizontalMask",
-	"SelectedHorizontalTexture",
-	"SlopeThresholdMask",
-	"SelectedVerticalTexture",
-	"LowLimitMask",
-	"SlopeThresholdAction",
-	"SlopeThresholdIndex",
-	"HeightHighLimit",
-}
+// ApplyPreset применяет пресет к миру профиля и записывает в журнал одно событие
+// с именем пресета, замененными слотами и путем к резервной копии.
+// Пресеты всех семейств с этим именем (биом и растительность) применяются за один проход.
+func ApplyPreset(profile *Profile, presetName string) (ApplyResult, error) {
+	var result ApplyResult
+	presetBlocks, err := loadPresetFamilies(presetName, profile.TemplateValues())
+	if err == nil {
+		result, err = replaceSections(profile, presetBlocks, presetName)
+	}
+	if err != nil && !result.Changed() {
+		Logger.Error("preset apply failed", "preset", presetName, "profile", profile.Name, "error", err)
+		return result, err
+	}
 
-// Функция для замены блоков в .ini файле на блоки из пресета
-func ReplaceBlocksInIni(sessionFilePath, presetFilePath, projectName string) {
-	// Считываем блоки из пресета
-	presetBlocks := ParsePresetBlocks(presetFilePath)
+	logPresetApplied(profile, presetName, result)
+	rememberApplied(profile, presetName)
+	return result, err
+}
 
-	// Открываем файл сессии для чтения с системной кодировкой
-	sessionFile, err := os.Open(sessionFilePath)
-	if err != nil {
-		fmt.Printf("Error opening session file: %v\n", err)
-		return
+// ApplyPresetFile применяет пресет, которого может не быть в папке пресетов (например, результат
+// смешивания), к миру профиля
+func ApplyPresetFile(profile *Profile, file *PresetFile) (ApplyResult, error) {
+	blocks := file.blocks()
+	if err := validatePreset(file.Metadata.Name, blocks, nil); err != nil {
+		return ApplyResult{}, err
 	}
-
-	// Открываем временный файл для записи (замена блоков будет происходить здесь)
-	tempFilePath := sessionFilePath + ".tmp"
-	tempFile, err := os.Create(tempFilePath)
-	if err != nil {
-		fmt.Printf("Error creating temp file: %v\n", err)
-		sessionFile.Close()
-		return
+	result, err := replaceSections(profile, blocks, file.Metadata.Name)
+	if err != nil && !result.Changed() {
+		Logger.Error("preset apply failed", "preset", file.Metadata.Name, "profile", profile.Name, "error", err)
+		return result, err
 	}
 
-	// Оборачиваем ридеры и райтеры для работы с системной кодировкой
-	reader := transform.NewReader(sessionFile, charmap.Windows1251.NewDecoder())
-	writer := transform.NewWriter(tempFile, charmap.Windows1251.NewEncoder())
+	logPresetApplied(profile, file.Metadata.Name, result)
+	rememberApplied(profile, file.Metadata.Name)
+	return result, err
+}
 
-	// Сканируем файл построчно
-	scanner := bufio.NewScanner(reader)
-	inBlock := false
-	var currentBlock PresetBlock
+// logPresetApplied записывает в журнал событие применения пресета
+func logPresetApplied(profile *Profile, presetName string, result ApplyResult) {
+	Logger.Info("preset applied",
+		"preset", presetName,
+		"profile", profile.Name,
+		"project", profile.ProjectName,
+		"world", profile.WorldName(),
+		"slots", result.SlotsReplaced,
+		"inserted", result.SlotsInserted,
+		"reset", result.SlotsReset,
+		"tools", result.ToolsReplaced,
+		"tools_inserted", result.ToolsInserted,
+		"missing", result.SlotsMissing,
+		"unknown_keys", result.UnknownKeys,
+		"backup", result.BackupPath,
+	)
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+// PresetPath возвращает путь к локальному файлу пресета биома по его имени
+func PresetPath(presetName string) string {
+	return BiomeFamily.Path(presetName)
+}
 
-		// Извлекаем номер слота из заголовка блока
-		re := regexp.MustCompile(`MaterialPairSlot(\d+)`)
-		matches := re.FindStringSubmatch(line)
-		if len(matches) == 2 {
-			slotNumber := matches[1]
-
-			// Формируем ожидаемый заголовок блока для текущего слота
-			expectedBlockHeader := fmt.Sprintf("[Session/dlc\\%s\\data\\levels\\%s\\%s.w2w/Tools/TerrainEdit/MaterialPairSlot%s]", projectName, projectName, projectName, slotNumber)
-
-			// Определяем начало нового блока, сравнивая с ожидаемым заголовком
-			if line == expectedBlockHeader {
-				if inBlock {
-					// Проверяем, есть ли этот блок в пресете
-					if newBlock, found := presetBlocks[currentBlock.Header]; found {
-						// Если блок найден, заменяем его содержимым из пресета
-						writeBlockToFile(newBlock, writer)
-						fmt.Printf("Block replaced: %s\n", currentBlock.Header)
-					} else {
-						// Если блок не найден, записываем старый блок как есть
-						writeBlockToFile(currentBlock, writer)
-					}
-				}
+// Функция для замены блоков в .ini файле на блоки из пресета для мира проекта из профиля.
+// Пресет может содержать секции любых видов из реестра sectionKinds: слоты материалов,
+// настройки инструментов TerrainEdit и кисти растительности. Значения проверяются по таблицам
+// видов до чтения файла сессий.
+func ReplaceBlocksInIni(profile *Profile, presetFilePath string) (ApplyResult, error) {
+	// Считываем блоки из пресета любого поддерживаемого формата
+	presetBlocks, err := loadPresetTemplate(presetFilePath, profile.TemplateValues())
+	if err != nil {
+		return ApplyResult{}, err
+	}
+	if err := validatePreset(presetFilePath, presetBlocks, nil); err != nil {
+		return ApplyResult{}, err
+	}
+	return replaceSections(profile, presetBlocks, filepath.Base(presetFilePath))
+}
 
-				// Начинаем новый блок
-				currentBlock = PresetBlock{
-					Header: line,
-					Values: make(map[string]string),
-				}
-				inBlock = true
-			} else if inBlock {
-				// Парсим ключ-значение в блоке
-				parts := strings.Split(line, "=")
-				if len(parts) == 2 {
-					key := strings.TrimSpace(parts[0])
-					value := strings.TrimSpace(parts[1])
-					currentBlock.Values[key] = value
-
-					// Если достигли конца блока (ключ "HeightHighLimit"), блок завершен
-					if key == "HeightHighLimit" {
-						if newBlock, found := presetBlocks[currentBlock.Header]; found {
-							// Если блок найден в пресете, заменяем его содержимым из пресета
-							writeBlockToFile(newBlock, writer)
-							fmt.Printf("Block replaced: %s\n", currentBlock.Header)
-						} else {
-							// Если блок не найден, записываем старый блок как есть
-							writeBlockToFile(currentBlock, writer)
-						}
-						inBlock = false
-					}
-				}
-			} else {
-				// Записываем строки, не относящиеся к блокам, напрямую в новый файл
-				writer.Write([]byte(line + "\r\n")) // Добавляем \r\n для DOS-стиля
-			}
+// replaceSections записывает проверенные блоки пресета в секции мира проекта из профиля.
+// Недостающие секции создаются с полным набором ключей, если это разрешено настройками
+// профиля; слоты мира сверх пресета сбрасываются или выключаются по profile.Slots.Extra.
+// Перед записью делается резервная копия по настройкам профиля. Если для части секций
+// в файле нет места и создавать их нельзя, остальные все равно заменяются,
+// а ошибка *SlotMissingError возвращается вместе с результатом.
+// Записанные изменения попадают в ApplyHistory под именем description.
+func replaceSections(profile *Profile, presetBlocks map[string]PresetBlock, description string) (ApplyResult, error) {
+	doc, err := openSessionsForApply(profile)
+	if err != nil {
+		return ApplyResult{}, err
+	}
+	before := doc.clone()
+
+	world := profile.WorldName()
+	result := applyToWorld(doc, profile, profile.ProjectName, world, presetBlocks)
+	if result.Changed() {
+		result.BackupPath, err = saveAppliedDocument(profile, doc)
+		if err != nil {
+			return result, err
 		}
+		ApplyHistory.record(profile, description, before, doc)
 	}
 
-	// Проверка на ошибки сканирования
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading session file: %v\n", err)
-		sessionFile.Close()
-		tempFile.Close()
-		return
+	if len(result.SlotsMissing) > 0 {
+		return result, &SlotMissingError{World: world, Slots: result.SlotsMissing}
 	}
+	return result, nil
+}
 
-	// Закрываем оригинальный файл после завершения чтения
-	sessionFile.Close()
+// openSessionsForApply проверяет настройки профиля и читает файл сессий перед применением пресетов
+func openSessionsForApply(profile *Profile) (*sessionDocument, error) {
+	if err := ValidateExtraSlots(profile.Slots.Extra); err != nil {
+		return nil, err
+	}
+	if err := ValidateUnknownKeys(profile.Slots.Keys.Unknown); err != nil {
+		return nil, err
+	}
 
-	// Если файл завершился на блоке, проверяем последний блок
-	if inBlock {
-		if newBlock, found := presetBlocks[currentBlock.Header]; found {
-			// Если блок найден в пресете, заменяем его содержимым из пресета
-			writeBlockToFile(newBlock, writer)
-			fmt.Printf("Block replaced: %s\n", currentBlock.Header)
-		} else {
-			// Если блок не найден, записываем старый блок как есть
-			writeBlockToFile(currentBlock, writer)
-		}
+	// Редактор перезапишет файл сессий при выходе, поэтому менять его, пока редактор открыт, бессмысленно
+	if running, err := editorRunning(); err != nil {
+		Logger.Warn("failed to check REDkit editor process", "error", err)
+	} else if running {
+		return nil, ErrEditorRunning
 	}
 
-	// Закрываем временный файл
-	tempFile.Close()
+	return readSessionDocument(profile.FilePath)
+}
 
-	// Заменяем оригинальный файл на обновленный
-	err = os.Remove(sessionFilePath)
+// saveAppliedDocument делает резервную копию файла сессий и записывает измененный документ.
+// Возвращает путь к резервной копии.
+func saveAppliedDocument(profile *Profile, doc *sessionDocument) (string, error) {
+	backupPath, err := CreateBackup(profile.FilePath, profile.Backup)
 	if err != nil {
-		fmt.Printf("Error removing original file: %v\n", err)
-		return
+		return "", err
+	}
+	if err := writeSessionDocument(profile.FilePath, doc); err != nil {
+		return backupPath, err
 	}
+	return backupPath, nil
+}
 
-	err = os.Rename(tempFilePath, sessionFilePath)
-	if err != nil {
-		fmt.Printf("Error renaming temp file: %v\n", err)
-		return
+// applyToWorld применяет блоки пресета к секциям одного мира проекта в документе.
+// Слоты, для которых секций нет и создавать их нельзя, перечисляются в SlotsMissing.
+func applyToWorld(doc *sessionDocument, profile *Profile, projectName, world string, presetBlocks map[string]PresetBlock) ApplyResult {
+	var result ApplyResult
+	keySettings := profile.Slots.Keys
+
+	byRef, refs := presetSections(presetBlocks)
+	for _, ref := range refs {
+		header := ref.header(projectName, world)
+		section := doc.section(header)
+		if section == nil {
+			if !profile.Slots.InsertMissing {
+				result.SlotsMissing = append(result.SlotsMissing, ref.label())
+				continue
+			}
+			// Новая секция всегда получает полный набор ключей
+			keys, values, unknown := keySettings.normalize(ref.Kind, nil, byRef[ref], true)
+			section = &iniSection{Header: header}
+			section.setKeys(keys, values)
+			doc.insertSection(projectName, world, ref, section)
+			result.addSection(ref, true)
+			result.reportUnknownKeys(keySettings, ref.label(), unknown)
+			Logger.Debug("block inserted", "header", header)
+			continue
+		}
+		keys, values, unknown := keySettings.normalize(ref.Kind, section, byRef[ref], false)
+		section.setKeys(keys, values)
+		result.addSection(ref, false)
+		result.reportUnknownKeys(keySettings, ref.label(), unknown)
+		Logger.Debug("block replaced", "header", section.Header)
 	}
 
-	fmt.Println("Replacement completed successfully.")
+	for _, section := range doc.worldSlots(projectName, world) {
+		ref, _ := kindOf(section.Header)
+		if _, inPreset := byRef[ref]; inPreset {
+			continue
+		}
+		if section.applyExtraSlotMode(profile.Slots.Extra, keySettings) {
+			result.SlotsReset = append(result.SlotsReset, ref.label())
+			Logger.Debug("block reset", "header", section.Header, "mode", profile.Slots.Extra)
+		}
+	}
+	return result
 }
 
 // Функция для считывания блоков из файла пресетов
-func ParsePresetBlocks(filePath string) map[string]PresetBlock {
+func ParsePresetBlocks(filePath string) (map[string]PresetBlock, error) {
 	blocks := make(map[string]PresetBlock)
 
 	// Открываем файл с системной кодировкой
 	file, err := os.Open(filePath)
 	if err != nil {
-		fmt.Printf("Error opening preset file: %v\n", err)
-		return blocks
+		return nil, fmt.Errorf("%s: %w", T("error.presetOpen"), err)
 	}
 	defer file.Close()
 
@@ -204,11 +235,11 @@ func ParsePresetBlocks(filePath string) map[string]PresetBlock {
 			}
 			inBlock = true
 		} else if inBlock {
-			// Парсим ключ-значение в блоке
-			parts := strings.Split(line, "=")
-			if len(parts) == 2 {
-				key := strings.TrimSpace(parts[0])
-				value := strings.TrimSpace(parts[1])
+			// Парсим ключ-значение в блоке, запоминая порядок ключей
+			if key, value, ok := splitKeyValue(line); ok {
+				if _, exists := currentBlock.Values[key]; !exists {
+					currentBlock.Keys = append(currentBlock.Keys, key)
+				}
 				currentBlock.Values[key] = value
 			}
 		}
@@ -219,20 +250,10 @@ func ParsePresetBlocks(filePath string) map[string]PresetBlock {
 		blocks[currentBlock.Header] = currentBlock
 	}
 
-	fmt.Printf("Parsed %d blocks from preset file.\n", len(blocks))
-	return blocks
-}
-
-// Функция для записи блока в файл с DOS-стилем перевода строк
-func writeBlockToFile(block PresetBlock, writer io.Writer) {
-	writer.Write([]byte(block.Header + "\r\n")) // DOS-стиль перевода строки \r\n
-	for i, key := range keysOrder {
-		if value, exists := block.Values[key]; exists {
-			if i == len(keysOrder)-1 {
-				writer.Write([]byte(fmt.Sprintf("%s=%s\r\n", key, value))) // Последняя строка тоже с \r\n
-			} else {
-				writer.Write([]byte(fmt.Sprintf("%s=%s\r\n", key, value)))
-			}
-		}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", T("error.presetRead", "Path", filePath), err)
 	}
+
+	Logger.Debug("preset parsed", "path", filePath, "blocks", len(blocks))
+	return blocks, nil
 }
@@ -1,41 +1,86 @@
 package modules
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // GitHub API для получения содержимого репозитория
 const (
-	githubRepoAPI = "https://api.github.com/repos/nowaytofindavailableone/redkit3biometool/contents/biomebrushes"
-	presetsFolder = "./presets" // Папка для хранения загруженных пресетов
+	githubRepoAPI        = "https://api.github.com/repos/nowaytofindavailableone/redkit3biometool/contents/biomebrushes"
+	githubFoliageRepoAPI = "https://api.github.com/repos/nowaytofindavailableone/redkit3biometool/contents/foliagebrushes"
+	presetsFolder        = "./presets"         // Папка для хранения загруженных пресетов биомов
+	foliagePresetsFolder = "./foliage_presets" // Папка для хранения загруженных пресетов растительности
 )
 
-// FetchAndConvertBiomeBrushes загружает JSON файлы с GitHub, конвертирует их в TXT и сохраняет локально
-func FetchAndConvertBiomeBrushes() error {
-	// Создаем папку presets, если она не существует
-	if _, err := os.Stat(presetsFolder); os.IsNotExist(err) {
-		err := os.Mkdir(presetsFolder, 0755)
+// fetchedSumsFile - файл в папке семейства с хешами пресетов в том виде, в каком их записала загрузка.
+// По нему загрузка отличает свои файлы от пресетов, которые пользователь изменил или создал сам.
+const fetchedSumsFile = ".fetched.sum"
+
+// FetchResult - итог загрузки пресетов из источников
+type FetchResult struct {
+	Updated []string // Пресеты, которые загрузка записала или обновила
+	Kept    []string // Локальные пресеты с правками, которые загрузка не перезаписала
+}
+
+// FetchAndConvertBiomeBrushes загружает пресеты из источников профиля (адреса GitHub API),
+// конвертирует их в собственный формат и сохраняет локально. Пресеты, измененные после загрузки
+// или созданные пользователем, перезаписываются только с overwrite.
+func FetchAndConvertBiomeBrushes(sources []string, overwrite bool) (FetchResult, error) {
+	return fetchAndConvert(sources, presetsFolder, overwrite)
+}
+
+// FetchAndConvertFoliageBrushes загружает пресеты кистей растительности так же, как пресеты биомов
+func FetchAndConvertFoliageBrushes(sources []string, overwrite bool) (FetchResult, error) {
+	return fetchAndConvert(sources, foliagePresetsFolder, overwrite)
+}
+
+// fetchAndConvert загружает пресеты из всех источников в локальную папку семейства
+func fetchAndConvert(sources []string, folder string, overwrite bool) (FetchResult, error) {
+	var result FetchResult
+	// Создаем папку, если она не существует
+	if _, err := os.Stat(folder); os.IsNotExist(err) {
+		err := os.Mkdir(folder, 0755)
 		if err != nil {
-			return fmt.Errorf("ошибка при создании директории пресетов: %v", err)
+			return result, fmt.Errorf("%s: %v", T("error.presetsDirCreate"), err)
+		}
+		Logger.Info("presets folder created", "path", folder)
+	}
+
+	sums := readFetchedSums(folder)
+	defer writeFetchedSums(folder, sums)
+	for _, source := range sources {
+		if err := fetchBrushesFrom(source, folder, overwrite, sums, &result); err != nil {
+			return result, err
 		}
-		fmt.Println("Папка 'presets' успешно создана.")
 	}
 
-	// Отправляем запрос к API GitHub для получения содержимого папки biomebrushes
-	resp, err := http.Get(githubRepoAPI)
+	return result, nil
+}
+
+// fetchBrushesFrom загружает и конвертирует пресеты из одной папки репозитория GitHub:
+// пронумерованный JSON и пресеты собственного формата (JSON и YAML)
+func fetchBrushesFrom(source, folder string, overwrite bool, sums map[string]string, result *FetchResult) error {
+	// Отправляем запрос к API GitHub для получения содержимого папки с пресетами
+	resp, err := http.Get(source)
 	if err != nil {
-		return fmt.Errorf("error fetching biome brushes from GitHub: %v", err)
+		return fmt.Errorf("%s: %v", T("error.fetchPresets"), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("error: failed to fetch biome brushes from GitHub, status: %s", resp.Status)
+		return fmt.Errorf("%s", T("error.fetchPresetsStatus", "Status", resp.Status))
 	}
 
 	// Читаем ответ и распаковываем JSON
@@ -48,25 +93,53 @@ func FetchAndConvertBiomeBrushes() error {
 
 	err = json.NewDecoder(resp.Body).Decode(&contents)
 	if err != nil {
-		return fmt.Errorf("error decoding GitHub API response: %v", err)
+		return fmt.Errorf("%s: %v", T("error.githubDecode"), err)
 	}
 
-	// Обрабатываем JSON файлы
 	for _, file := range contents {
-		// Проверяем тип файла и расширение, чтобы обработать только JSON файлы
-		if filepath.Ext(file.Name) == ".json" && file.Type == "file" {
-			// Загружаем содержимое JSON файла
-			brush, err := fetchJSONFromURL(file.DownloadURL)
-			if err != nil {
-				fmt.Printf("error fetching JSON file %s: %v\n", file.Name, err)
-				continue
-			}
+		ext := strings.ToLower(filepath.Ext(file.Name))
+		if file.Type != "file" || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := fetchFromURL(file.DownloadURL)
+		if err != nil {
+			Logger.Warn("failed to fetch preset", "file", file.Name, "error", err)
+			continue
+		}
+		preset, err := decodeFetchedPreset(file.Name, data)
+		if err != nil {
+			Logger.Warn("failed to parse fetched preset", "file", file.Name, "error", err)
+			continue
+		}
 
-			// Преобразуем JSON в текстовый формат и сохраняем
-			txtFileName := strings.Replace(filepath.Base(file.Name), ".json", ".txt", 1)
-			err = ConvertJSONToTxt(brush, filepath.Join(presetsFolder, txtFileName))
-			if err != nil {
-				return fmt.Errorf("error converting JSON to TXT for file %s: %v", file.Name, err)
+		// Сохраняем в собственном формате; источник записывается в метаданные для фильтра по источнику
+		name := strings.TrimSuffix(filepath.Base(file.Name), filepath.Ext(file.Name))
+		preset.Metadata.Source = source
+		content, err := encodePresetFile(preset)
+		if err != nil {
+			return fmt.Errorf("%s: %v", T("error.presetConvert", "File", file.Name), err)
+		}
+		path := filepath.Join(folder, name+".json")
+		dumpPath := filepath.Join(folder, name+".txt")
+		dump := isLegacyDump(dumpPath, data)
+		if !overwrite && localPresetEdited(folder, name, source, sums, dump) {
+			Logger.Warn("local preset kept", "path", path, "source", source)
+			result.Kept = append(result.Kept, name)
+			continue
+		}
+		current, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(current, content) || dump {
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				return fmt.Errorf("%s: %v", T("error.presetConvert", "File", file.Name), err)
+			}
+			result.Updated = append(result.Updated, name)
+			Logger.Debug("preset converted", "path", path)
+		}
+		sums[name+".json"] = contentSum(content)
+		// TXT прежней загрузки заменен пресетом собственного формата и больше не нужен
+		if dump {
+			if err := os.Remove(dumpPath); err != nil {
+				Logger.Warn("legacy preset dump not removed", "path", dumpPath, "error", err)
 			}
 		}
 	}
@@ -74,115 +147,186 @@ func FetchAndConvertBiomeBrushes() error {
 	return nil
 }
 
-func replaceDoubleBrackets(text string) string {
-	text = strings.Replace(text, "[[", "[", -1)
-	text = strings.Replace(text, "]]", "]", -1)
-	return text
-}
-
-// fetchJSONFromURL загружает JSON содержимое по URL
-func fetchJSONFromURL(url string) (map[string]map[string]interface{}, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка при получении JSON по URL: %v", err)
+// decodeFetchedPreset разбирает загруженный файл: пресет собственного формата (есть formatVersion)
+// или пронумерованный JSON репозитория
+func decodeFetchedPreset(fileName string, data []byte) (*PresetFile, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.presetParse", "Path", fileName), err)
+	}
+	if isNativePreset(&document) {
+		return decodeNativePreset(fileName, &document)
+	}
+	if !strings.EqualFold(filepath.Ext(fileName), ".json") {
+		return nil, fmt.Errorf("%s", T("error.presetFormat", "Path", fileName))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ошибка: не удалось получить JSON по URL, статус: %s", resp.Status)
+	var numbered map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &numbered); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.decodeJSON"), err)
 	}
+	blocks := make(map[string]PresetBlock)
+	for _, block := range numberedJSONBlocks(numbered) {
+		blocks[block.Header] = block
+	}
+	name := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	return newPresetFile(name, blocks), nil
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
+// localPresetEdited сообщает, есть ли в папке пресет с этим именем, который загрузка не должна
+// перезаписывать: файл другого формата, файл, измененный после загрузки, или пресет пользователя.
+// Файлы без записи в .fetched.sum, но с этим источником в метаданных, записаны загрузкой
+// прежних версий, которая перезаписывала их при каждом запуске. dump - TXT с этим именем
+// совпадает с тем, что прежняя загрузка записала бы для этого пресета, и правкой не считается.
+func localPresetEdited(folder, name, source string, sums map[string]string, dump bool) bool {
+	for _, ext := range presetExtensions {
+		if ext == ".json" || (ext == ".txt" && dump) {
+			continue
+		}
+		if FileExists(filepath.Join(folder, name+ext)) {
+			return true
+		}
+	}
+	current, err := os.ReadFile(filepath.Join(folder, name+".json"))
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при чтении тела ответа: %v", err)
+		return false
+	}
+	if sum, known := sums[name+".json"]; known {
+		return sum != contentSum(current)
+	}
+	var local struct {
+		Metadata struct {
+			Source string `json:"source"`
+		} `json:"metadata"`
 	}
+	return json.Unmarshal(current, &local) != nil || local.Metadata.Source != source
+}
 
-	var data map[string]map[string]interface{}
-	err = json.Unmarshal(body, &data)
+// isLegacyDump сообщает, что файл path - TXT, который прежние версии записывали при загрузке
+// пронумерованного JSON data (ConvertJSONToTxt): заголовки секций и ключи в порядке таблицы вида.
+// Версии до поддержки неизвестных ключей их не записывали, поэтому подходят оба варианта.
+func isLegacyDump(path string, data []byte) bool {
+	current, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка декодирования JSON: %v", err)
+		return false
 	}
-
-	return data, nil
+	var numbered map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &numbered); err != nil {
+		return false
+	}
+	blocks := numberedJSONBlocks(numbered)
+	text := string(current)
+	return text == legacyDumpText(blocks, true) || text == legacyDumpText(blocks, false)
 }
 
-// ConvertJSONToTxt преобразует JSON структуру в нужный текстовый формат и сохраняет в файл
-func ConvertJSONToTxt(jsonData map[string]map[string]interface{}, outputFileName string) error {
+// legacyDumpText повторяет вывод ConvertJSONToTxt; без unknownKeys пишутся только известные ключи
+func legacyDumpText(blocks []PresetBlock, unknownKeys bool) string {
 	var sb strings.Builder
-
-	// Определяем фиксированный порядок ключей для правильного вывода
-	keysOrder := []string{
-		"VerticalMask",
-		"VerticalUVMult",
-		"VerticalUVScaleMask",
-		"HeightLowLimit",
-		"Probability",
-		"PresetEnabled",
-		"HighLimitMask",
-		"HorizontalMask",
-		"SelectedHorizontalTexture",
-		"SlopeThresholdMask",
-		"SelectedVerticalTexture",
-		"LowLimitMask",
-		"SlopeThresholdAction",
-		"SlopeThresholdIndex",
-		"HeightHighLimit",
-	}
-
-	// Преобразуем JSON структуру в нужный текстовый формат
-	for i := 1; i <= len(jsonData); i++ { // Гарантируем порядок обработки
-		blockKey := fmt.Sprintf("%d", i)
-		block := jsonData[blockKey]
-
-		// Печатаем заголовок блока
-		if path, exists := block["path"]; exists {
-			header := fmt.Sprintf("[%s]\n", replaceDoubleBrackets(fmt.Sprintf("%v", path)))
-			sb.WriteString(header)
-		}
-
-		// Печатаем параметры в правильном порядке
-		for _, key := range keysOrder {
-			if val, exists := block[key]; exists {
-				// Преобразуем значение в строку и заменяем двойные квадратные скобки
-				sb.WriteString(fmt.Sprintf("%s=%v\n", key, val))
+	for _, block := range blocks {
+		kind := materialPairKind
+		if block.Header != "" {
+			sb.WriteString(block.Header + "\n")
+			if ref, ok := kindOf(block.Header); ok {
+				kind = ref.Kind
+			}
+		}
+		for _, key := range block.Keys {
+			if unknownKeys || kind.isKnown(key) {
+				sb.WriteString(key + "=" + block.Values[key] + "\n")
 			}
 		}
-		// Удаляем добавление пустой строки
 	}
+	return sb.String()
+}
 
-	// Заменяем двойные скобки во всем текстовом содержимом
-	finalOutput := replaceDoubleBrackets(sb.String())
+// contentSum возвращает SHA-256 содержимого файла в шестнадцатеричном виде
+func contentSum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
 
-	// Сохраняем результат в файл
-	err := ioutil.WriteFile(outputFileName, []byte(finalOutput), 0644)
+// readFetchedSums читает хеши загруженных пресетов: строки "хеш имя_файла"
+func readFetchedSums(folder string) map[string]string {
+	sums := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(folder, fetchedSumsFile))
 	if err != nil {
-		return fmt.Errorf("ошибка записи в файл: %v", err)
+		return sums
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			sums[fields[1]] = fields[0]
+		}
 	}
+	return sums
+}
 
-	fmt.Printf("Успешно сохранено в %s\n", outputFileName)
-	return nil
+// writeFetchedSums записывает хеши загруженных пресетов по именам файлов
+func writeFetchedSums(folder string, sums map[string]string) {
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s %s\n", sums[name], name)
+	}
+	if err := os.WriteFile(filepath.Join(folder, fetchedSumsFile), []byte(sb.String()), 0644); err != nil {
+		Logger.Warn("fetched preset sums not saved", "folder", folder, "error", err)
+	}
 }
 
-// FetchAvailablePresets возвращает список доступных пресетов из локальной папки
-func FetchAvailablePresets() ([]string, error) {
-	// Проверяем, существует ли папка
-	if _, err := os.Stat(presetsFolder); os.IsNotExist(err) {
-		return nil, fmt.Errorf("папка 'presets' не найдена")
+func replaceDoubleBrackets(text string) string {
+	text = strings.Replace(text, "[[", "[", -1)
+	text = strings.Replace(text, "]]", "]", -1)
+	return text
+}
+
+// fetchFromURL загружает содержимое файла по URL
+func fetchFromURL(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.fetchJSON"), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", T("error.fetchJSONStatus", "Status", resp.Status))
 	}
 
-	// Читаем содержимое папки presets
-	files, err := ioutil.ReadDir(presetsFolder)
+	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при чтении директории пресетов: %v", err)
+		return nil, fmt.Errorf("%s: %v", T("error.readBody"), err)
 	}
+	return body, nil
+}
 
+// FetchAvailablePresets возвращает имена пресетов всех семейств из локальных папок
+// во всех поддерживаемых форматах (JSON, YAML, TXT). Имя, под которым есть и пресет биома, и пресет растительности, встречается один раз.
+func FetchAvailablePresets() ([]string, error) {
+	seen := make(map[string]bool)
 	var presets []string
-	for _, file := range files {
-		if strings.HasSuffix(file.Name(), ".txt") {
-			presetName := strings.TrimSuffix(file.Name(), ".txt")
-			presets = append(presets, presetName)
+	foundFolder := false
+	for _, family := range PresetFamilies {
+		names, err := family.available()
+		if os.IsNotExist(err) {
+			continue
 		}
+		if err != nil {
+			return nil, err
+		}
+		foundFolder = true
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				presets = append(presets, name)
+			}
+		}
+	}
+	if !foundFolder {
+		return nil, fmt.Errorf("%s", T("error.presetsFolderMissing"))
 	}
 
+	sort.Strings(presets)
 	return presets, nil
 }
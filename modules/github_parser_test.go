@@ -0,0 +1,179 @@
+package modules
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// fetchTestServer отдает листинг папки пресетов в формате GitHub API и файлы из files
+func fetchTestServer(t *testing.T, files map[string]string) string {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/contents" {
+			var listing []map[string]string
+			for name := range files {
+				listing = append(listing, map[string]string{"name": name, "type": "file", "download_url": server.URL + "/raw/" + name})
+			}
+			listing = append(listing, map[string]string{"name": "README.md", "type": "file", "download_url": server.URL + "/raw/README.md"})
+			json.NewEncoder(w).Encode(listing)
+			return
+		}
+		content, ok := files[filepath.Base(r.URL.Path)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/contents"
+}
+
+// fetchedPreset читает сохраненный загрузкой пресет
+func fetchedPreset(t *testing.T, path string) *PresetFile {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := decodeFetchedPreset(path, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestFetchConvertsNumberedAndNativePresets(t *testing.T) {
+	source := fetchTestServer(t, map[string]string{
+		"swamp.json": `{"1": {"path": "[[Session/dlc\\proj\\data\\levels\\world\\world.w2w/Tools/TerrainEdit/MaterialPairSlot1]]", "Probability": 70}}`,
+		"rocks.yaml": "formatVersion: 1\nslots:\n  - path: Tools/TerrainEdit/MaterialPairSlot2\n    values:\n      Probability: 30\n",
+	})
+	folder := t.TempDir()
+
+	result, err := fetchAndConvert([]string{source}, folder, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(result.Updated)
+	if !slices.Equal(result.Updated, []string{"rocks", "swamp"}) || len(result.Kept) > 0 {
+		t.Errorf("итог загрузки %+v", result)
+	}
+	for name, want := range map[string]string{"swamp": "Tools/TerrainEdit/MaterialPairSlot1", "rocks": "Tools/TerrainEdit/MaterialPairSlot2"} {
+		file := fetchedPreset(t, filepath.Join(folder, name+".json"))
+		if len(file.Slots) != 1 || file.Slots[0].Path != want || file.Metadata.Source != source {
+			t.Errorf("%s: слоты %+v, источник %q", name, file.Slots, file.Metadata.Source)
+		}
+	}
+
+	// Повторная загрузка того же содержимого ничего не переписывает
+	result, err = fetchAndConvert([]string{source}, folder, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Updated) > 0 || len(result.Kept) > 0 {
+		t.Errorf("повторная загрузка: %+v", result)
+	}
+}
+
+func TestFetchKeepsLocalEdits(t *testing.T) {
+	source := fetchTestServer(t, map[string]string{
+		"swamp.json":  `{"1": {"path": "[[Session/dlc\\proj\\data\\levels\\world\\world.w2w/Tools/TerrainEdit/MaterialPairSlot1]]", "Probability": 70}}`,
+		"forest.json": `{"1": {"path": "[[Session/dlc\\proj\\data\\levels\\world\\world.w2w/Tools/TerrainEdit/MaterialPairSlot1]]", "Probability": 40}}`,
+	})
+	folder := t.TempDir()
+	if _, err := fetchAndConvert([]string{source}, folder, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// swamp изменен после загрузки, forest.yaml пользователь создал сам
+	swamp := filepath.Join(folder, "swamp.json")
+	edited := fetchedPreset(t, swamp)
+	edited.Slots[0].Values.Values["Probability"] = "95"
+	if err := savePresetFile(swamp, edited); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, "forest.yaml"), []byte("formatVersion: 1\nslots: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(folder, "forest.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := fetchAndConvert([]string{source}, folder, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(result.Kept)
+	if !slices.Equal(result.Kept, []string{"forest", "swamp"}) || len(result.Updated) > 0 {
+		t.Errorf("итог загрузки %+v", result)
+	}
+	if got := fetchedPreset(t, swamp).Slots[0].Values.Values["Probability"]; got != "95" {
+		t.Errorf("локальная правка потеряна: Probability = %q", got)
+	}
+	if FileExists(filepath.Join(folder, "forest.json")) {
+		t.Error("загрузка создала forest.json рядом с пресетом пользователя")
+	}
+
+	result, err = fetchAndConvert([]string{source}, folder, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(result.Updated)
+	if !slices.Equal(result.Updated, []string{"forest", "swamp"}) || len(result.Kept) > 0 {
+		t.Errorf("итог загрузки с overwrite %+v", result)
+	}
+	if got := fetchedPreset(t, swamp).Slots[0].Values.Values["Probability"]; got != "70" {
+		t.Errorf("overwrite не заменил пресет: Probability = %q", got)
+	}
+}
+
+func TestFetchReplacesLegacyTxtDump(t *testing.T) {
+	header := `[Session/dlc\proj\data\levels\world\world.w2w/Tools/TerrainEdit/MaterialPairSlot1]`
+	source := fetchTestServer(t, map[string]string{
+		"swamp.json":  `{"1": {"path": "[[Session/dlc\\proj\\data\\levels\\world\\world.w2w/Tools/TerrainEdit/MaterialPairSlot1]]", "Probability": 70, "HeightLowLimit": 5}}`,
+		"forest.json": `{"1": {"path": "[[Session/dlc\\proj\\data\\levels\\world\\world.w2w/Tools/TerrainEdit/MaterialPairSlot1]]", "Probability": 40}}`,
+	})
+	folder := t.TempDir()
+	// swamp.txt записан загрузкой прежней версии, в forest.txt пользователь поменял вероятность
+	dumps := map[string]string{
+		"swamp.txt":  header + "\nHeightLowLimit=5\nProbability=70\n",
+		"forest.txt": header + "\nProbability=45\n",
+	}
+	for name, content := range dumps {
+		if err := os.WriteFile(filepath.Join(folder, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := fetchAndConvert([]string{source}, folder, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(result.Updated, []string{"swamp"}) || !slices.Equal(result.Kept, []string{"forest"}) {
+		t.Errorf("итог загрузки %+v", result)
+	}
+	if got := fetchedPreset(t, filepath.Join(folder, "swamp.json")).Slots[0].Values.Values["Probability"]; got != "70" {
+		t.Errorf("swamp.json: Probability = %q", got)
+	}
+	if FileExists(filepath.Join(folder, "swamp.txt")) {
+		t.Error("TXT прежней загрузки не удален")
+	}
+	if !FileExists(filepath.Join(folder, "forest.txt")) || FileExists(filepath.Join(folder, "forest.json")) {
+		t.Error("измененный TXT заменен загрузкой")
+	}
+
+	// Следующая загрузка уже ничего не меняет
+	result, err = fetchAndConvert([]string{source}, folder, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Updated) > 0 || !slices.Equal(result.Kept, []string{"forest"}) {
+		t.Errorf("повторная загрузка: %+v", result)
+	}
+}
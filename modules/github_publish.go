@@ -0,0 +1,387 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Адрес REST API GitHub и репозиторий пресетов по умолчанию
+const (
+	githubAPIURL     = "https://api.github.com"
+	githubRepository = "nowaytofindavailableone/redkit3biometool"
+)
+
+// GitHub создает форк в фоне; столько раз и с таким интервалом публикация ждет его основную ветку
+var (
+	forkWaitAttempts = 10
+	forkWaitInterval = 2 * time.Second
+)
+
+// GitHubSettings - доступ к GitHub для публикации пресетов
+type GitHubSettings struct {
+	Token      string `json:"token,omitempty"`      // Личный токен доступа с правом на публичные репозитории
+	APIURL     string `json:"api_url,omitempty"`    // Адрес REST API; пусто - https://api.github.com
+	Repository string `json:"repository,omitempty"` // Репозиторий пресетов owner/name; пусто - репозиторий по умолчанию
+}
+
+// LoadGitHubSettings возвращает настройки GitHub из config.json. Переменные окружения MBC_GITHUB_TOKEN,
+// MBC_GITHUB_API_URL и MBC_GITHUB_REPOSITORY важнее файла; пустые поля получают значения по умолчанию.
+func LoadGitHubSettings() (GitHubSettings, error) {
+	var settings GitHubSettings
+	config, err := LoadConfig()
+	if err != nil && !os.IsNotExist(err) {
+		return settings, err
+	}
+	if config != nil && config.GitHub != nil {
+		settings = *config.GitHub
+	}
+	if err := applyEnvOverrides(&settings, envPrefix+"GITHUB_"); err != nil {
+		return settings, err
+	}
+	if settings.APIURL == "" {
+		settings.APIURL = githubAPIURL
+	}
+	if settings.Repository == "" {
+		settings.Repository = githubRepository
+	}
+	return settings, nil
+}
+
+// PublishedFile - файл пресета в репозитории: путь от корня и содержимое
+type PublishedFile struct {
+	Path    string
+	Content []byte
+}
+
+// PresetPublication - то, что будет отправлено в pull request: заголовок и описание из метаданных
+// пресета и файлы всех его семейств в пронумерованном формате репозитория
+type PresetPublication struct {
+	Preset string
+	Title  string
+	Body   string
+	Files  []PublishedFile
+}
+
+// PublishResult - итог публикации
+type PublishResult struct {
+	URL        string // Адрес pull request
+	Number     int
+	Repository string // Репозиторий с веткой: сам репозиторий пресетов или форк пользователя
+	Branch     string
+	Files      []string
+}
+
+// PreparePublication читает и проверяет локальный пресет и готовит файлы и текст pull request.
+// Наследование и значения переменных по умолчанию раскрываются: в репозитории пресеты хранятся
+// в пронумерованном JSON, где нет ни extends, ни переменных.
+func PreparePublication(presetName string) (*PresetPublication, error) {
+	families := presetFamiliesOf(presetName)
+	if len(families) == 0 {
+		return nil, fmt.Errorf("%s", T("error.presetNotFound", "Name", presetName))
+	}
+
+	publication := &PresetPublication{Preset: presetName}
+	var metadata PresetMetadata
+	for _, family := range families {
+		path := family.Path(presetName)
+		blocks, err := loadPresetTemplate(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := validatePreset(path, blocks, family.Kinds); err != nil {
+			return nil, err
+		}
+		content, err := numberedJSON(blocks)
+		if err != nil {
+			return nil, err
+		}
+		publication.Files = append(publication.Files, PublishedFile{Path: family.RepoFolder + "/" + presetName + ".json", Content: content})
+
+		// Метаданные семейств дополняют друг друга, как в каталоге пресетов
+		if own, ok := readPresetMetadata(path); ok {
+			if metadata.Name == "" {
+				metadata.Name = own.Name
+			}
+			if metadata.Description == "" {
+				metadata.Description = own.Description
+			}
+			if metadata.Author == "" {
+				metadata.Author = own.Author
+			}
+			metadata.Tags = appendUnique(metadata.Tags, own.Tags...)
+		}
+	}
+	publication.Title, publication.Body = publicationText(presetName, metadata, publication.Files)
+	return publication, nil
+}
+
+// publicationText составляет заголовок и описание pull request. Текст пишется по-английски
+// независимо от языка интерфейса: его читают сопровождающие общего репозитория.
+func publicationText(presetName string, metadata PresetMetadata, files []PublishedFile) (string, string) {
+	name := metadata.Name
+	if name == "" {
+		name = presetName
+	}
+	title := "Add preset " + name
+
+	var body strings.Builder
+	if metadata.Description != "" {
+		body.WriteString(metadata.Description + "\n\n")
+	}
+	if metadata.Author != "" {
+		body.WriteString("- Author: " + metadata.Author + "\n")
+	}
+	if len(metadata.Tags) > 0 {
+		body.WriteString("- Tags: " + strings.Join(metadata.Tags, ", ") + "\n")
+	}
+	for _, file := range files {
+		body.WriteString("- File: `" + file.Path + "`\n")
+	}
+	return title, body.String()
+}
+
+// numberedJSON записывает блоки в пронумерованном формате репозитория: {"1": {"path": ..., ключи}, ...}.
+// Секции идут в порядке мира, ключи - в порядке таблицы вида; числа и логические значения без кавычек.
+func numberedJSON(blocks map[string]PresetBlock) ([]byte, error) {
+	byRef, refs := presetSections(blocks)
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, ref := range refs {
+		block := byRef[ref]
+		values := PresetValues{Keys: append([]string{"path"}, block.keys()...), Values: cloneValues(block.Values)}
+		values.Values["path"] = "/" + ref.Kind.suffix(ref.ID)
+		data, err := values.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:", strconv.Itoa(i+1))
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	indented.WriteByte('\n')
+	return indented.Bytes(), nil
+}
+
+// Символы, которые нельзя использовать в имени ветки
+var branchUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// publishBranch возвращает имя новой ветки для публикации; время делает его уникальным
+func publishBranch(presetName string, now time.Time) string {
+	return "preset/" + strings.Trim(branchUnsafe.ReplaceAllString(presetName, "-"), "-.") + "-" + now.Format("20060102-150405")
+}
+
+// PublishPreset отправляет пресет в репозиторий пресетов через REST API GitHub: создает ветку
+// (в самом репозитории, если у токена есть право записи, иначе в форке пользователя), записывает
+// в нее файлы пресета и открывает pull request в основную ветку репозитория.
+func PublishPreset(settings GitHubSettings, publication *PresetPublication) (*PublishResult, error) {
+	if settings.Token == "" {
+		return nil, fmt.Errorf("%s", T("publish.noToken"))
+	}
+	client := &githubClient{
+		baseURL: strings.TrimRight(settings.APIURL, "/"),
+		token:   settings.Token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+	upstream := settings.Repository
+
+	var repository struct {
+		DefaultBranch string `json:"default_branch"`
+		Permissions   struct {
+			Push bool `json:"push"`
+		} `json:"permissions"`
+	}
+	if err := client.do(http.MethodGet, "/repos/"+upstream, nil, &repository); err != nil {
+		return nil, err
+	}
+	var base struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if err := client.do(http.MethodGet, "/repos/"+upstream+"/git/ref/heads/"+repository.DefaultBranch, nil, &base); err != nil {
+		return nil, err
+	}
+
+	head := upstream
+	if !repository.Permissions.Push {
+		fork, err := client.fork(upstream, repository.DefaultBranch)
+		if err != nil {
+			return nil, err
+		}
+		head = fork
+	}
+
+	result := &PublishResult{Repository: head, Branch: publishBranch(publication.Preset, time.Now())}
+	ref := map[string]string{"ref": "refs/heads/" + result.Branch, "sha": base.Object.SHA}
+	if err := client.do(http.MethodPost, "/repos/"+head+"/git/refs", ref, nil); err != nil {
+		return nil, err
+	}
+	Logger.Debug("publish branch created", "repository", head, "branch", result.Branch)
+
+	for _, file := range publication.Files {
+		if err := client.putFile(head, result.Branch, file, publication.Preset); err != nil {
+			return nil, err
+		}
+		result.Files = append(result.Files, file.Path)
+	}
+
+	// Ветку из форка GitHub ищет по имени владельца форка
+	headRef := result.Branch
+	if head != upstream {
+		headRef = strings.SplitN(head, "/", 2)[0] + ":" + result.Branch
+	}
+	var pull struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	request := map[string]string{"title": publication.Title, "body": publication.Body, "head": headRef, "base": repository.DefaultBranch}
+	if err := client.do(http.MethodPost, "/repos/"+upstream+"/pulls", request, &pull); err != nil {
+		return nil, err
+	}
+	result.URL = pull.HTMLURL
+	result.Number = pull.Number
+
+	Logger.Info("preset published", "preset", publication.Preset, "repository", upstream, "head", headRef, "pull", result.URL)
+	return result, nil
+}
+
+// githubClient - запросы к REST API GitHub с токеном
+type githubClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// do выполняет запрос с телом JSON и разбирает ответ в result (если он не nil).
+// Ответ с кодом 300 и выше возвращается как *GitHubError.
+func (c *githubClient) do(method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("%s: %v", T("error.githubRequest"), err)
+	}
+	request.Header.Set("Accept", "application/vnd.github+json")
+	request.Header.Set("Authorization", "Bearer "+c.token)
+	request.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return fmt.Errorf("%s: %v", T("error.githubRequest"), err)
+	}
+	defer response.Body.Close()
+	Logger.Debug("github request", "method", method, "path", path, "status", response.StatusCode)
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+		var apiError struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(response.Body).Decode(&apiError)
+		return &GitHubError{Method: method, Path: path, Status: response.StatusCode, Message: apiError.Message}
+	}
+	if result != nil {
+		if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+			return fmt.Errorf("%s: %v", T("error.githubDecode"), err)
+		}
+	}
+	return nil
+}
+
+// fork создает форк репозитория для владельца токена (или возвращает существующий)
+// и ждет, пока в нем появится основная ветка
+func (c *githubClient) fork(upstream, branch string) (string, error) {
+	var fork struct {
+		FullName string `json:"full_name"`
+	}
+	if err := c.do(http.MethodPost, "/repos/"+upstream+"/forks", map[string]interface{}{}, &fork); err != nil {
+		return "", err
+	}
+	Logger.Debug("fork requested", "repository", upstream, "fork", fork.FullName)
+
+	for attempt := 1; ; attempt++ {
+		err := c.do(http.MethodGet, "/repos/"+fork.FullName+"/git/ref/heads/"+branch, nil, nil)
+		if err == nil {
+			return fork.FullName, nil
+		}
+		if attempt >= forkWaitAttempts || !isGitHubStatus(err, http.StatusNotFound, http.StatusConflict) {
+			return "", err
+		}
+		time.Sleep(forkWaitInterval)
+	}
+}
+
+// putFile записывает файл в ветку отдельным коммитом. Если файл уже есть (пресет обновляется),
+// GitHub требует sha его текущей версии.
+func (c *githubClient) putFile(repository, branch string, file PublishedFile, presetName string) error {
+	path := "/repos/" + repository + "/contents/" + escapePath(file.Path)
+	var existing struct {
+		SHA string `json:"sha"`
+	}
+	err := c.do(http.MethodGet, path+"?ref="+url.QueryEscape(branch), nil, &existing)
+	if err != nil && !isGitHubStatus(err, http.StatusNotFound) {
+		return err
+	}
+
+	request := map[string]string{
+		"message": "Add preset " + presetName,
+		"content": base64.StdEncoding.EncodeToString(file.Content),
+		"branch":  branch,
+	}
+	if existing.SHA != "" {
+		request["message"] = "Update preset " + presetName
+		request["sha"] = existing.SHA
+	}
+	return c.do(http.MethodPut, path, request, nil)
+}
+
+// escapePath экранирует каждую часть пути файла в репозитории
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// isGitHubStatus сообщает, что GitHub ответил одним из указанных кодов
+func isGitHubStatus(err error, statuses ...int) bool {
+	var githubErr *GitHubError
+	if !errors.As(err, &githubErr) {
+		return false
+	}
+	for _, status := range statuses {
+		if githubErr.Status == status {
+			return true
+		}
+	}
+	return false
+}
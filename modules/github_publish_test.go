@@ -0,0 +1,239 @@
+package modules
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGitHub - REST API GitHub для тестов публикации: репозиторий owner/presets с веткой main
+// и форк user/presets, у которого основная ветка появляется после forkDelay запросов
+type fakeGitHub struct {
+	t         *testing.T
+	push      bool              // Есть ли у токена право записи в репозиторий
+	existing  map[string]string // Файлы, которые уже есть в репозитории: путь → sha
+	forkDelay int               // Сколько раз ветка форка отвечает 404
+	failures  map[string]int    // "МЕТОД путь" → код ответа с ошибкой
+
+	mu       sync.Mutex
+	requests []string                     // "МЕТОД путь" в порядке запросов
+	bodies   map[string]map[string]string // Тело последнего запроса по "МЕТОД путь"
+}
+
+func newFakeGitHub(t *testing.T, push bool) *fakeGitHub {
+	return &fakeGitHub{t: t, push: push, existing: map[string]string{}, failures: map[string]int{}, bodies: map[string]map[string]string{}}
+}
+
+// start запускает сервер и возвращает настройки публикации для него
+func (g *fakeGitHub) start() GitHubSettings {
+	server := httptest.NewServer(g)
+	g.t.Cleanup(server.Close)
+
+	interval := forkWaitInterval
+	forkWaitInterval = time.Millisecond
+	g.t.Cleanup(func() { forkWaitInterval = interval })
+	return GitHubSettings{Token: "secret", APIURL: server.URL + "/", Repository: "owner/presets"}
+}
+
+func (g *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	request := r.Method + " " + r.URL.Path
+	g.requests = append(g.requests, request)
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		g.t.Errorf("%s без токена", request)
+	}
+	if r.Body != nil {
+		var body map[string]string
+		if json.NewDecoder(r.Body).Decode(&body) == nil {
+			g.bodies[request] = body
+		}
+	}
+
+	reply := func(status int, value interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(value)
+	}
+	if status, ok := g.failures[request]; ok {
+		reply(status, map[string]string{"message": "rejected"})
+		return
+	}
+
+	switch {
+	case request == "GET /repos/owner/presets":
+		reply(http.StatusOK, map[string]interface{}{"default_branch": "main", "permissions": map[string]bool{"push": g.push}})
+	case request == "GET /repos/owner/presets/git/ref/heads/main":
+		reply(http.StatusOK, map[string]interface{}{"object": map[string]string{"sha": "base-sha"}})
+	case request == "POST /repos/owner/presets/forks":
+		reply(http.StatusAccepted, map[string]string{"full_name": "user/presets"})
+	case request == "GET /repos/user/presets/git/ref/heads/main":
+		if g.forkDelay > 0 {
+			g.forkDelay--
+			reply(http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		reply(http.StatusOK, map[string]interface{}{"object": map[string]string{"sha": "base-sha"}})
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/git/refs"):
+		reply(http.StatusCreated, map[string]string{})
+	case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/contents/"):
+		file := r.URL.Path[strings.Index(r.URL.Path, "/contents/")+len("/contents/"):]
+		if sha, ok := g.existing[file]; ok {
+			reply(http.StatusOK, map[string]string{"sha": sha})
+			return
+		}
+		reply(http.StatusNotFound, map[string]string{"message": "Not Found"})
+	case r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/contents/"):
+		reply(http.StatusCreated, map[string]string{})
+	case request == "POST /repos/owner/presets/pulls":
+		reply(http.StatusCreated, map[string]interface{}{"number": 7, "html_url": "https://github.test/owner/presets/pull/7"})
+	default:
+		reply(http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+}
+
+// body возвращает тело последнего запроса
+func (g *fakeGitHub) body(request string) map[string]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.bodies[request]
+}
+
+// testPublication - публикация пресета swamp с одним файлом
+func testPublication() *PresetPublication {
+	return &PresetPublication{
+		Preset: "swamp",
+		Title:  "Add preset swamp",
+		Body:   "- File: `biomebrushes/swamp.json`\n",
+		Files:  []PublishedFile{{Path: "biomebrushes/swamp.json", Content: []byte("{}\n")}},
+	}
+}
+
+func TestPublishPresetWithPushAccess(t *testing.T) {
+	github := newFakeGitHub(t, true)
+	result, err := PublishPreset(github.start(), testPublication())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"GET /repos/owner/presets",
+		"GET /repos/owner/presets/git/ref/heads/main",
+		"POST /repos/owner/presets/git/refs",
+		"GET /repos/owner/presets/contents/biomebrushes/swamp.json",
+		"PUT /repos/owner/presets/contents/biomebrushes/swamp.json",
+		"POST /repos/owner/presets/pulls",
+	}
+	if !slices.Equal(github.requests, want) {
+		t.Errorf("запросы:\n%s\nожидалось:\n%s", strings.Join(github.requests, "\n"), strings.Join(want, "\n"))
+	}
+	if result.Repository != "owner/presets" || result.Number != 7 || result.URL != "https://github.test/owner/presets/pull/7" ||
+		!strings.HasPrefix(result.Branch, "preset/swamp-") || !slices.Equal(result.Files, []string{"biomebrushes/swamp.json"}) {
+		t.Errorf("итог %+v", result)
+	}
+
+	if ref := github.body("POST /repos/owner/presets/git/refs"); ref["ref"] != "refs/heads/"+result.Branch || ref["sha"] != "base-sha" {
+		t.Errorf("ветка создана запросом %v", ref)
+	}
+	put := github.body("PUT /repos/owner/presets/contents/biomebrushes/swamp.json")
+	if put["content"] != base64.StdEncoding.EncodeToString([]byte("{}\n")) || put["branch"] != result.Branch ||
+		put["message"] != "Add preset swamp" || put["sha"] != "" {
+		t.Errorf("файл записан запросом %v", put)
+	}
+	pull := github.body("POST /repos/owner/presets/pulls")
+	if pull["head"] != result.Branch || pull["base"] != "main" || pull["title"] != "Add preset swamp" {
+		t.Errorf("pull request открыт запросом %v", pull)
+	}
+}
+
+func TestPublishPresetUpdatesExistingFile(t *testing.T) {
+	github := newFakeGitHub(t, true)
+	github.existing["biomebrushes/swamp.json"] = "old-sha"
+	if _, err := PublishPreset(github.start(), testPublication()); err != nil {
+		t.Fatal(err)
+	}
+	put := github.body("PUT /repos/owner/presets/contents/biomebrushes/swamp.json")
+	if put["sha"] != "old-sha" || put["message"] != "Update preset swamp" {
+		t.Errorf("файл обновлен запросом %v", put)
+	}
+}
+
+func TestPublishPresetThroughFork(t *testing.T) {
+	github := newFakeGitHub(t, false)
+	github.forkDelay = 2
+	result, err := PublishPreset(github.start(), testPublication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Repository != "user/presets" {
+		t.Errorf("ветка в репозитории %s, ожидался форк user/presets", result.Repository)
+	}
+	if got := github.body("POST /repos/owner/presets/pulls")["head"]; got != "user:"+result.Branch {
+		t.Errorf("head = %q, ожидалось user:%s", got, result.Branch)
+	}
+	for _, request := range []string{"POST /repos/owner/presets/forks", "POST /repos/user/presets/git/refs", "PUT /repos/user/presets/contents/biomebrushes/swamp.json"} {
+		if !slices.Contains(github.requests, request) {
+			t.Errorf("нет запроса %s", request)
+		}
+	}
+	if slices.Contains(github.requests, "POST /repos/owner/presets/git/refs") {
+		t.Error("без права записи ветка создана в репозитории пресетов")
+	}
+}
+
+func TestPublishPresetErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		push   bool
+		fail   string // Запрос, на который сервер отвечает ошибкой
+		status int
+		never  bool // Ветка форка так и не появляется
+	}{
+		{"нет доступа к репозиторию", true, "GET /repos/owner/presets", http.StatusUnauthorized, false},
+		{"ветка уже есть", true, "POST /repos/owner/presets/git/refs", http.StatusUnprocessableEntity, false},
+		{"файл не записан", true, "PUT /repos/owner/presets/contents/biomebrushes/swamp.json", http.StatusConflict, false},
+		{"pull request не открыт", true, "POST /repos/owner/presets/pulls", http.StatusUnprocessableEntity, false},
+		{"форк не создан", false, "POST /repos/owner/presets/forks", http.StatusForbidden, false},
+		{"форк не готов", false, "", http.StatusNotFound, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			github := newFakeGitHub(t, tt.push)
+			if tt.fail != "" {
+				github.failures[tt.fail] = tt.status
+			}
+			if tt.never {
+				github.forkDelay = forkWaitAttempts + 1
+			}
+			_, err := PublishPreset(github.start(), testPublication())
+			var githubErr *GitHubError
+			if !errors.As(err, &githubErr) || !errors.Is(err, ErrGitHub) || githubErr.Status != tt.status {
+				t.Fatalf("ошибка %v, ожидалась GitHubError с кодом %d", err, tt.status)
+			}
+			if slices.Contains(github.requests, "POST /repos/owner/presets/pulls") && tt.fail != "POST /repos/owner/presets/pulls" {
+				t.Error("pull request открыт после ошибки")
+			}
+		})
+	}
+}
+
+func TestPublishPresetWithoutToken(t *testing.T) {
+	_, err := PublishPreset(GitHubSettings{APIURL: "http://127.0.0.1:1", Repository: "owner/presets"}, testPublication())
+	if err == nil || err.Error() != T("publish.noToken") {
+		t.Errorf("ошибка %v, ожидалось сообщение об отсутствии токена", err)
+	}
+}
+
+func TestPublishBranch(t *testing.T) {
+	now := time.Date(2024, 5, 17, 9, 30, 5, 0, time.UTC)
+	if got := publishBranch("Болото / swamp v2", now); got != "preset/swamp-v2-20240517-093005" {
+		t.Errorf("ветка %q", got)
+	}
+}
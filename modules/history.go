@@ -0,0 +1,259 @@
+package modules
+
+import (
+	"sync"
+	"time"
+)
+
+// keyChange - изменение одного ключа секции. Had/Has - был ли ключ до и после изменения.
+type keyChange struct {
+	Key    string
+	Before string
+	After  string
+	Had    bool
+	Has    bool
+}
+
+// sectionPatch - изменения ключей одной секции файла сессий
+type sectionPatch struct {
+	Header  string
+	Created bool   // Секция создана применением; при отмене удаляется, если после нее ничего не менялось
+	Anchor  string // Заголовок предыдущей секции, чтобы вернуть созданную секцию на место при повторе
+	Keys    []keyChange
+}
+
+// Patch - одно применение (или другая правка) как набор обратимых изменений ключей.
+// Отмена возвращает только те ключи, которые с тех пор не менялись, поэтому правки,
+// сделанные в REDkit после применения, не теряются.
+type Patch struct {
+	Description string
+	Time        time.Time
+	FilePath    string
+	Backup      BackupSettings
+	Sections    []sectionPatch
+}
+
+// HistoryEntry - строка списка истории для интерфейса
+type HistoryEntry struct {
+	Description string
+	Time        time.Time
+	Undone      bool // Шаг отменен и может быть повторен
+}
+
+// History - стек отмены и повтора применений в пределах сеанса программы
+type History struct {
+	mu       sync.Mutex
+	undo     []*Patch
+	redo     []*Patch
+	onChange func()
+}
+
+// История применений текущего сеанса
+var ApplyHistory = &History{}
+
+// Entries возвращает шаги истории: сначала выполненные (старые сверху), затем отмененные
+func (h *History) Entries() []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries := make([]HistoryEntry, 0, len(h.undo)+len(h.redo))
+	for _, patch := range h.undo {
+		entries = append(entries, HistoryEntry{Description: patch.Description, Time: patch.Time})
+	}
+	for i := len(h.redo) - 1; i >= 0; i-- {
+		entries = append(entries, HistoryEntry{Description: h.redo[i].Description, Time: h.redo[i].Time, Undone: true})
+	}
+	return entries
+}
+
+// CanUndo сообщает, есть ли шаг для отмены
+func (h *History) CanUndo() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.undo) > 0
+}
+
+// CanRedo сообщает, есть ли шаг для повтора
+func (h *History) CanRedo() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.redo) > 0
+}
+
+// record добавляет шаг с изменениями между снимком документа до правки и документом после нее.
+// Новый шаг очищает стек повтора.
+func (h *History) record(profile *Profile, description string, before, after *sessionDocument) {
+	patch := diffDocuments(before, after)
+	if len(patch.Sections) == 0 {
+		return
+	}
+	patch.Description = description
+	patch.Time = time.Now()
+	patch.FilePath = profile.FilePath
+	patch.Backup = profile.Backup
+
+	h.mu.Lock()
+	h.undo = append(h.undo, patch)
+	h.redo = nil
+	h.mu.Unlock()
+	h.changed()
+}
+
+// Undo отменяет последний шаг. Возвращает шаг и ключи, которые не вернулись, потому что
+// их успели изменить после применения (в виде "заголовок ключ").
+func (h *History) Undo() (*Patch, []string, error) {
+	return h.step(true)
+}
+
+// Redo повторяет последний отмененный шаг с теми же правилами, что и Undo
+func (h *History) Redo() (*Patch, []string, error) {
+	return h.step(false)
+}
+
+func (h *History) step(undo bool) (*Patch, []string, error) {
+	h.mu.Lock()
+	from, to := &h.redo, &h.undo
+	if undo {
+		from, to = &h.undo, &h.redo
+	}
+	if len(*from) == 0 {
+		h.mu.Unlock()
+		return nil, nil, nil
+	}
+	patch := (*from)[len(*from)-1]
+	h.mu.Unlock()
+
+	skipped, err := patch.apply(undo)
+	if err != nil {
+		return patch, nil, err
+	}
+
+	h.mu.Lock()
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, patch)
+	h.mu.Unlock()
+
+	action := "redo"
+	if undo {
+		action = "undo"
+	}
+	Logger.Info("history "+action, "step", patch.Description, "path", patch.FilePath, "skipped", skipped)
+	h.changed()
+	return patch, skipped, nil
+}
+
+// OnChange задает функцию, вызываемую после каждого изменения истории
+func (h *History) OnChange(callback func()) {
+	h.mu.Lock()
+	h.onChange = callback
+	h.mu.Unlock()
+}
+
+func (h *History) changed() {
+	h.mu.Lock()
+	onChange := h.onChange
+	h.mu.Unlock()
+	if onChange != nil {
+		onChange()
+	}
+}
+
+// apply записывает шаг в файл сессий в обратную (undo) или прямую сторону
+func (p *Patch) apply(undo bool) ([]string, error) {
+	if running, err := editorRunning(); err != nil {
+		Logger.Warn("failed to check REDkit editor process", "error", err)
+	} else if running {
+		return nil, ErrEditorRunning
+	}
+
+	doc, err := readSessionDocument(p.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var skipped []string
+	changed := false
+	for _, patch := range p.Sections {
+		sectionSkipped, sectionChanged := patch.apply(doc, undo)
+		skipped = append(skipped, sectionSkipped...)
+		changed = changed || sectionChanged
+	}
+	if !changed {
+		return skipped, nil
+	}
+
+	if _, err := CreateBackup(p.FilePath, p.Backup); err != nil {
+		return nil, err
+	}
+	return skipped, writeSessionDocument(p.FilePath, doc)
+}
+
+// apply меняет ключи секции: значение возвращается, только если сейчас в файле то, что записал шаг
+func (sp sectionPatch) apply(doc *sessionDocument, undo bool) ([]string, bool) {
+	section := doc.section(sp.Header)
+	if section == nil {
+		if undo || !sp.Created {
+			// Секцию удалили после применения: возвращать нечего
+			return []string{sp.Header}, false
+		}
+		section = &iniSection{Header: sp.Header, Values: make(map[string]string)}
+		doc.insertAfter(sp.Anchor, section)
+	}
+
+	var skipped []string
+	changed := false
+	for _, change := range sp.Keys {
+		expected, expectedPresent, target, targetPresent := change.After, change.Has, change.Before, change.Had
+		if !undo {
+			expected, expectedPresent, target, targetPresent = change.Before, change.Had, change.After, change.Has
+		}
+
+		current, present := section.Values[change.Key]
+		if present != expectedPresent || (present && current != expected) {
+			skipped = append(skipped, sp.Header+" "+change.Key)
+			continue
+		}
+		if targetPresent {
+			section.set(change.Key, target)
+		} else {
+			section.removeKey(change.Key)
+		}
+		changed = true
+	}
+
+	// Созданная применением секция удаляется при отмене, если от нее ничего не осталось
+	if undo && sp.Created && len(section.Keys) == 0 {
+		doc.removeSection(sp.Header)
+		changed = true
+	}
+	return skipped, changed
+}
+
+// diffDocuments собирает изменения ключей между двумя состояниями документа
+func diffDocuments(before, after *sessionDocument) *Patch {
+	patch := &Patch{}
+	anchor := ""
+	for _, section := range after.sections {
+		old := before.section(section.Header)
+		sp := sectionPatch{Header: section.Header, Created: old == nil, Anchor: anchor}
+		anchor = section.Header
+		if old == nil {
+			old = &iniSection{Values: map[string]string{}}
+		}
+
+		for _, key := range section.Keys {
+			oldValue, had := old.Values[key]
+			if !had || oldValue != section.Values[key] {
+				sp.Keys = append(sp.Keys, keyChange{Key: key, Before: oldValue, After: section.Values[key], Had: had, Has: true})
+			}
+		}
+		for _, key := range old.Keys {
+			if _, has := section.Values[key]; !has {
+				sp.Keys = append(sp.Keys, keyChange{Key: key, Before: old.Values[key], Had: true})
+			}
+		}
+		if len(sp.Keys) > 0 || sp.Created {
+			patch.Sections = append(patch.Sections, sp)
+		}
+	}
+	return patch
+}
@@ -0,0 +1,185 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testSection возвращает заголовок секции мира тестового профиля, например testSection("Tools/TerrainEdit")
+func testSection(suffix string) string {
+	return worldSectionPrefix("proj", "world") + suffix + "]"
+}
+
+// sessionsText собирает текст файла сессий из строк так, как его записывает writeSessionDocument
+func sessionsText(lines ...string) string {
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// newTestProfile создает файл сессий во временной папке и профиль мира proj/world без резервных копий.
+// Конфигурация, история применений и проверка процесса редактора подменяются на время теста.
+func newTestProfile(t *testing.T, text string) *Profile {
+	t.Helper()
+	dir := t.TempDir()
+	SetConfigPath(filepath.Join(dir, "config.json"))
+	t.Cleanup(func() { SetConfigPath("") })
+
+	history := ApplyHistory
+	ApplyHistory = &History{}
+	t.Cleanup(func() { ApplyHistory = history })
+
+	running := editorRunning
+	editorRunning = func() (bool, error) { return false, nil }
+	t.Cleanup(func() { editorRunning = running })
+
+	path := filepath.Join(dir, sessionsFileName)
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &Profile{Name: "test", FilePath: path, ProjectName: "proj", World: "world", Slots: defaultSlotSettings()}
+}
+
+// readTestSessions возвращает текущий текст файла сессий профиля
+func readTestSessions(t *testing.T, profile *Profile) string {
+	t.Helper()
+	data, err := os.ReadFile(profile.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// editTestSessions читает файл сессий, меняет его функцией edit и записывает обратно.
+// Возвращает документ до правки и после нее.
+func editTestSessions(t *testing.T, profile *Profile, edit func(doc *sessionDocument)) (*sessionDocument, *sessionDocument) {
+	t.Helper()
+	doc, err := readSessionDocument(profile.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := doc.clone()
+	edit(doc)
+	if err := writeSessionDocument(profile.FilePath, doc); err != nil {
+		t.Fatal(err)
+	}
+	return before, doc
+}
+
+var historyTestSessions = sessionsText(
+	"; REDkit sessions",
+	testSection("Tools/TerrainEdit"),
+	"Size=10",
+	"Intensity=0.5",
+	testSection("Tools/TerrainEdit/MaterialPairSlot1"),
+	"Probability=40",
+	"PresetEnabled=true",
+)
+
+func TestHistoryUndoRedoRoundTrip(t *testing.T) {
+	profile := newTestProfile(t, historyTestSessions)
+	history := &History{}
+
+	before, after := editTestSessions(t, profile, func(doc *sessionDocument) {
+		tool := doc.section(testSection("Tools/TerrainEdit"))
+		tool.set("Size", "25")
+		tool.removeKey("Intensity")
+		doc.section(testSection("Tools/TerrainEdit/MaterialPairSlot1")).set("Probability", "80")
+		created := &iniSection{Header: testSection("Tools/TerrainEdit/MaterialPairSlot2"), Values: map[string]string{}}
+		created.set("Probability", "15")
+		doc.insertAfter(testSection("Tools/TerrainEdit/MaterialPairSlot1"), created)
+	})
+	applied := after.render()
+	history.record(profile, "forest", before, after)
+
+	if !history.CanUndo() || history.CanRedo() {
+		t.Fatal("после записи должен быть доступен только шаг отмены")
+	}
+
+	patch, skipped, err := history.Undo()
+	if err != nil || patch == nil || len(skipped) > 0 {
+		t.Fatalf("отмена: %v, пропущено %v", err, skipped)
+	}
+	if got := readTestSessions(t, profile); got != historyTestSessions {
+		t.Errorf("после отмены файл не совпадает с исходным:\n%s", got)
+	}
+	if entries := history.Entries(); len(entries) != 1 || !entries[0].Undone || entries[0].Description != "forest" {
+		t.Errorf("история после отмены: %+v", entries)
+	}
+
+	if _, skipped, err := history.Redo(); err != nil || len(skipped) > 0 {
+		t.Fatalf("повтор: %v, пропущено %v", err, skipped)
+	}
+	if got := readTestSessions(t, profile); got != applied {
+		t.Errorf("после повтора файл не совпадает с примененным:\n%s\nожидалось:\n%s", got, applied)
+	}
+	if history.CanRedo() || !history.CanUndo() {
+		t.Error("после повтора шаг должен снова стать доступен для отмены")
+	}
+}
+
+func TestHistoryUndoKeepsLaterEdits(t *testing.T) {
+	profile := newTestProfile(t, historyTestSessions)
+	history := &History{}
+
+	before, after := editTestSessions(t, profile, func(doc *sessionDocument) {
+		doc.section(testSection("Tools/TerrainEdit")).set("Size", "25")
+		doc.section(testSection("Tools/TerrainEdit/MaterialPairSlot1")).set("Probability", "80")
+	})
+	history.record(profile, "forest", before, after)
+
+	// Пользователь поменял вероятность слота в REDkit после применения
+	editTestSessions(t, profile, func(doc *sessionDocument) {
+		doc.section(testSection("Tools/TerrainEdit/MaterialPairSlot1")).set("Probability", "55")
+	})
+
+	_, skipped, err := history.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{testSection("Tools/TerrainEdit/MaterialPairSlot1") + " Probability"}; !slices.Equal(skipped, want) {
+		t.Errorf("пропущенные ключи %v, ожидалось %v", skipped, want)
+	}
+	doc, err := readSessionDocument(profile.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.section(testSection("Tools/TerrainEdit")).Values["Size"]; got != "10" {
+		t.Errorf("Size после отмены = %q, ожидалось 10", got)
+	}
+	if got := doc.section(testSection("Tools/TerrainEdit/MaterialPairSlot1")).Values["Probability"]; got != "55" {
+		t.Errorf("правка после применения потеряна: Probability = %q", got)
+	}
+}
+
+func TestHistoryRecordClearsRedo(t *testing.T) {
+	profile := newTestProfile(t, historyTestSessions)
+	history := &History{}
+
+	record := func(size string) {
+		before, after := editTestSessions(t, profile, func(doc *sessionDocument) {
+			doc.section(testSection("Tools/TerrainEdit")).set("Size", size)
+		})
+		history.record(profile, "size "+size, before, after)
+	}
+	record("20")
+	if _, _, err := history.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	record("30")
+
+	if history.CanRedo() {
+		t.Error("новый шаг должен очищать стек повтора")
+	}
+	if entries := history.Entries(); len(entries) != 1 || entries[0].Description != "size 30" {
+		t.Errorf("история: %+v", entries)
+	}
+
+	// Правка без изменений в историю не попадает
+	before, after := editTestSessions(t, profile, func(doc *sessionDocument) {})
+	history.record(profile, "noop", before, after)
+	if len(history.Entries()) != 1 {
+		t.Error("пустой шаг попал в историю")
+	}
+}
@@ -0,0 +1,120 @@
+package modules
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jeandeaual/go-locale"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+// Каталоги сообщений: locales/active.<язык>.json, ключ - идентификатор сообщения
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Поддерживаемые языки интерфейса; первый используется, если язык ОС не поддерживается
+var SupportedLanguages = []language.Tag{language.English, language.Russian, language.Polish}
+
+var (
+	localeMu  sync.RWMutex
+	bundle    *i18n.Bundle
+	localizer *i18n.Localizer
+	activeTag = language.English
+)
+
+// loadBundle читает все встроенные каталоги сообщений
+func loadBundle() (*i18n.Bundle, error) {
+	b := i18n.NewBundle(language.English)
+	b.RegisterUnmarshalFunc("json", json.Unmarshal)
+	for _, tag := range SupportedLanguages {
+		path := fmt.Sprintf("locales/active.%s.json", tag)
+		if _, err := b.LoadMessageFileFS(localeFiles, path); err != nil {
+			return nil, fmt.Errorf("каталог сообщений %s: %v", path, err)
+		}
+	}
+	return b, nil
+}
+
+// InitLocale выбирает язык интерфейса: явная настройка (поле language в config.json или MBC_LANGUAGE),
+// иначе язык ОС. Неподдерживаемые языки заменяются английским.
+func InitLocale(override string) error {
+	b, err := loadBundle()
+	if err != nil {
+		return err
+	}
+
+	var preferred []string
+	if env := os.Getenv(envPrefix + "LANGUAGE"); env != "" {
+		preferred = append(preferred, env)
+	}
+	if override != "" {
+		preferred = append(preferred, override)
+	}
+	if len(preferred) == 0 {
+		if osLocales, err := locale.GetLocales(); err == nil {
+			preferred = osLocales
+		}
+	}
+
+	matcher := language.NewMatcher(SupportedLanguages)
+	tags := make([]language.Tag, 0, len(preferred))
+	for _, name := range preferred {
+		if tag, err := language.Parse(strings.ReplaceAll(name, "_", "-")); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	_, index, _ := matcher.Match(tags...)
+
+	localeMu.Lock()
+	bundle = b
+	activeTag = SupportedLanguages[index]
+	localizer = i18n.NewLocalizer(b, activeTag.String())
+	localeMu.Unlock()
+	return nil
+}
+
+// Language возвращает код выбранного языка интерфейса (en, ru, pl)
+func Language() string {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	return activeTag.String()
+}
+
+// T возвращает сообщение каталога на выбранном языке. Аргументы задаются парами имя-значение
+// и подставляются в шаблон: T("project.modified", "Time", t) для "изменен {{.Time}}".
+// Если сообщения нет в каталоге, возвращается его идентификатор.
+func T(id string, args ...interface{}) string {
+	localeMu.RLock()
+	l := localizer
+	localeMu.RUnlock()
+
+	if l == nil {
+		// Каталог еще не загружен (например, в тестах): используем английский
+		if err := InitLocale(language.English.String()); err != nil {
+			return id
+		}
+		localeMu.RLock()
+		l = localizer
+		localeMu.RUnlock()
+	}
+
+	var data map[string]interface{}
+	if len(args) > 0 {
+		data = make(map[string]interface{}, len(args)/2)
+		for i := 0; i+1 < len(args); i += 2 {
+			data[fmt.Sprint(args[i])] = args[i+1]
+		}
+	}
+
+	message, err := l.Localize(&i18n.LocalizeConfig{MessageID: id, TemplateData: data})
+	if err != nil {
+		return id
+	}
+	return message
+}
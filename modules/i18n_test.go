@@ -0,0 +1,116 @@
+package modules
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+// Вызовы T("id", ...) и modules.T("id", ...) в исходниках
+var translationCallPattern = regexp.MustCompile(`\bT\("([a-zA-Z0-9_.]+)"`)
+
+// Строки использования команд CLI хранятся идентификаторами: usage: "usage.apply"
+var usageIDPattern = regexp.MustCompile(`usage:\s*"([a-zA-Z0-9_.]+)"`)
+
+// Идентификаторы ошибок-констант: localizedError{id: "error.x"}
+var localizedErrorPattern = regexp.MustCompile(`localizedError\{id:\s*"([a-zA-Z0-9_.]+)"`)
+
+func readCatalog(t *testing.T, lang string) map[string]string {
+	t.Helper()
+	data, err := localeFiles.ReadFile("locales/active." + lang + ".json")
+	if err != nil {
+		t.Fatalf("каталог %s: %v", lang, err)
+	}
+	catalog := make(map[string]string)
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		t.Fatalf("каталог %s: %v", lang, err)
+	}
+	return catalog
+}
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	reference := readCatalog(t, "en")
+	for _, tag := range SupportedLanguages[1:] {
+		lang := tag.String()
+		catalog := readCatalog(t, lang)
+		for id := range reference {
+			if _, ok := catalog[id]; !ok {
+				t.Errorf("%s: нет перевода для %q", lang, id)
+			}
+		}
+		for id := range catalog {
+			if _, ok := reference[id]; !ok {
+				t.Errorf("%s: ключ %q отсутствует в английском каталоге", lang, id)
+			}
+		}
+	}
+}
+
+func TestSourceKeysAreTranslated(t *testing.T) {
+	var files []string
+	for _, dir := range []string{".", ".."} {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, matches...)
+	}
+
+	used := make(map[string]string)
+	for _, file := range files {
+		if filepath.Base(file) == "i18n_test.go" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pattern := range []*regexp.Regexp{translationCallPattern, usageIDPattern, localizedErrorPattern} {
+			for _, match := range pattern.FindAllSubmatch(data, -1) {
+				used[string(match[1])] = file
+			}
+		}
+	}
+	if len(used) == 0 {
+		t.Fatal("в исходниках не найдено ни одного вызова T")
+	}
+
+	ids := make([]string, 0, len(used))
+	for id := range used {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, tag := range SupportedLanguages {
+		catalog := readCatalog(t, tag.String())
+		for _, id := range ids {
+			if _, ok := catalog[id]; !ok {
+				t.Errorf("%s: ключ %q из %s отсутствует в каталоге", tag, id, used[id])
+			}
+		}
+	}
+}
+
+func TestInitLocaleOverride(t *testing.T) {
+	t.Setenv(envPrefix+"LANGUAGE", "")
+	defer InitLocale("en")
+
+	if err := InitLocale("pl_PL"); err != nil {
+		t.Fatal(err)
+	}
+	if Language() != "pl" {
+		t.Errorf("Language() = %q, ожидается pl", Language())
+	}
+	if got := T("gui.cancel"); got != "Anuluj" {
+		t.Errorf("T(gui.cancel) = %q, ожидается Anuluj", got)
+	}
+	if err := InitLocale("de"); err != nil {
+		t.Fatal(err)
+	}
+	if Language() != "en" {
+		t.Errorf("неподдерживаемый язык: Language() = %q, ожидается en", Language())
+	}
+}
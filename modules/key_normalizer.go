@@ -0,0 +1,129 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Что делать с ключами секции, которых нет в таблице ее вида (например, поля новых версий REDkit)
+const (
+	UnknownKeysKeep = "keep" // Сохранять в исходном порядке после известных ключей
+	UnknownKeysDrop = "drop" // Удалять
+	UnknownKeysWarn = "warn" // Сохранять и сообщать о них в журнале и в итоге применения
+)
+
+// KeySettings задает нормализацию ключей секций при применении пресета
+type KeySettings struct {
+	FillDefaults bool              `json:"fill_defaults"`      // Дополнять недостающие ключи значениями по умолчанию
+	Unknown      string            `json:"unknown,omitempty"`  // Неизвестные ключи: keep, drop, warn
+	Defaults     map[string]string `json:"defaults,omitempty"` // Значения по умолчанию, заменяющие встроенные
+}
+
+// Настройки ключей для новых профилей
+func defaultKeySettings() KeySettings {
+	return KeySettings{FillDefaults: true, Unknown: UnknownKeysKeep}
+}
+
+// ValidateUnknownKeys проверяет режим обработки неизвестных ключей (пусто - keep)
+func ValidateUnknownKeys(mode string) error {
+	switch mode {
+	case "", UnknownKeysKeep, UnknownKeysDrop, UnknownKeysWarn:
+		return nil
+	}
+	return fmt.Errorf("%s", T("error.unknownKeysMode", "Mode", mode))
+}
+
+// defaults возвращает таблицу значений по умолчанию вида: встроенную, дополненную значениями профиля
+// для известных ключей этого вида
+func (k KeySettings) defaults(kind *SectionKind) map[string]string {
+	table := make(map[string]string, len(kind.Keys))
+	for _, spec := range kind.Keys {
+		if spec.Default != "" {
+			table[spec.Name] = spec.Default
+		}
+	}
+	for key, value := range k.Defaults {
+		if kind.isKnown(key) {
+			table[key] = value
+		}
+	}
+	return table
+}
+
+// normalize собирает ключи секции из блока пресета. Известные ключи вида идут в порядке его таблицы,
+// недостающие берутся из таблицы по умолчанию (всегда, если fill равно true, иначе по FillDefaults),
+// а если значения по умолчанию нет (например, у кистей) - из существующей секции.
+// Неизвестные ключи существующей секции и пресета обрабатываются по политике Unknown:
+// сначала ключи секции в исходном порядке, затем новые ключи пресета в порядке пресета.
+// Возвращает ключи, значения и список неизвестных ключей, которые попали в секцию.
+func (k KeySettings) normalize(kind *SectionKind, existing *iniSection, block PresetBlock, fill bool) ([]string, map[string]string, []string) {
+	defaults := k.defaults(kind)
+	var keys []string
+	values := make(map[string]string)
+
+	for _, key := range kind.keyOrder() {
+		if value, ok := block.Values[key]; ok {
+			values[key] = value
+		} else if value, ok := defaults[key]; ok && (fill || k.FillDefaults) {
+			values[key] = value
+		} else if value, ok := existing.value(key); ok {
+			values[key] = value
+		} else {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if k.Unknown == UnknownKeysDrop {
+		return keys, values, nil
+	}
+
+	var unknown []string
+	addUnknown := func(key, value string) {
+		if kind.isKnown(key) {
+			return
+		}
+		if _, seen := values[key]; seen {
+			return
+		}
+		if presetValue, ok := block.Values[key]; ok {
+			value = presetValue
+		}
+		keys = append(keys, key)
+		values[key] = value
+		unknown = append(unknown, key)
+	}
+	if existing != nil {
+		for _, key := range existing.Keys {
+			addUnknown(key, existing.Values[key])
+		}
+	}
+	for _, key := range block.keys() {
+		addUnknown(key, block.Values[key])
+	}
+	return keys, values, unknown
+}
+
+// keys возвращает ключи блока в порядке пресета. Для блоков, собранных без порядка, сначала идут
+// известные ключи вида секции, затем остальные по алфавиту.
+func (b PresetBlock) keys() []string {
+	if len(b.Keys) > 0 || len(b.Values) == 0 {
+		return b.Keys
+	}
+	var keys, rest []string
+	ref, known := kindOf(b.Header)
+	if known {
+		for _, key := range ref.Kind.keyOrder() {
+			if _, ok := b.Values[key]; ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	for key := range b.Values {
+		if !known || !ref.Kind.isKnown(key) {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
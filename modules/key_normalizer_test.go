@@ -0,0 +1,60 @@
+package modules
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizePartialBrushPresetKeepsExistingKeys(t *testing.T) {
+	for _, kind := range []*SectionKind{terrainToolKind, foliageBrushKind} {
+		t.Run(kind.Name, func(t *testing.T) {
+			existing := &iniSection{
+				Header: "[Worlds/test.w2w/" + kind.suffix("Brush") + "]",
+				Keys:   []string{"Size", kind.Keys[1].Name, "Custom"},
+				Values: map[string]string{"Size": "10", kind.Keys[1].Name: "0.7", "Custom": "x"},
+			}
+			block := PresetBlock{Header: existing.Header, Keys: []string{"Size"}, Values: map[string]string{"Size": "25"}}
+
+			keys, values, unknown := defaultKeySettings().normalize(kind, existing, block, false)
+
+			want := map[string]string{"Size": "25", kind.Keys[1].Name: "0.7", "Custom": "x"}
+			if len(values) != len(want) {
+				t.Errorf("значения %v, ожидалось %v", values, want)
+			}
+			for key, value := range want {
+				if values[key] != value {
+					t.Errorf("%s = %q, ожидалось %q", key, values[key], value)
+				}
+			}
+			if wantKeys := []string{"Size", kind.Keys[1].Name, "Custom"}; !slices.Equal(keys, wantKeys) {
+				t.Errorf("ключи %v, ожидалось %v", keys, wantKeys)
+			}
+			if !slices.Equal(unknown, []string{"Custom"}) {
+				t.Errorf("неизвестные ключи %v", unknown)
+			}
+		})
+	}
+}
+
+func TestNormalizeMaterialSlotDefaults(t *testing.T) {
+	existing := &iniSection{
+		Keys:   []string{"Probability", "HeightLowLimit"},
+		Values: map[string]string{"Probability": "40", "HeightLowLimit": "12"},
+	}
+	block := PresetBlock{Keys: []string{"PresetEnabled"}, Values: map[string]string{"PresetEnabled": "true"}}
+
+	// С FillDefaults недостающие ключи слота берутся из таблицы по умолчанию
+	_, values, _ := defaultKeySettings().normalize(materialPairKind, existing, block, false)
+	if values["Probability"] != "100" || values["HeightLowLimit"] != "0" || values["PresetEnabled"] != "true" {
+		t.Errorf("с заполнением по умолчанию: %v", values)
+	}
+	if len(values) != len(materialPairKind.Keys) {
+		t.Errorf("заполнено %d ключей из %d", len(values), len(materialPairKind.Keys))
+	}
+
+	// Без FillDefaults ключи, которых нет в пресете, остаются как в секции
+	keys, values, _ := KeySettings{Unknown: UnknownKeysKeep}.normalize(materialPairKind, existing, block, false)
+	if values["Probability"] != "40" || values["HeightLowLimit"] != "12" || len(keys) != 3 {
+		t.Errorf("без заполнения: %v %v", keys, values)
+	}
+}
@@ -0,0 +1,193 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Имя файла журнала и параметры ротации
+const (
+	logFileName     = "MaterialBrushChanger.log"
+	logMaxSize      = 1 << 20 // Размер файла, после которого начинается новый
+	logMaxBackups   = 5       // Сколько старых файлов журнала хранить (.log.1 ... .log.5)
+	logBufferLength = 500     // Сколько последних строк держать для панели журнала в GUI
+)
+
+// Logger - общий журнал приложения. До вызова InitLogging пишет в stderr.
+var Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// LogLines хранит последние строки журнала для панели в GUI
+var LogLines = &LogBuffer{limit: logBufferLength}
+
+// LogBuffer - кольцевой буфер строк журнала с уведомлением подписчика о новых строках
+type LogBuffer struct {
+	mu       sync.Mutex
+	lines    []string
+	limit    int
+	partial  []byte
+	onChange func()
+}
+
+// Write реализует io.Writer: разбивает поток на строки и сохраняет последние limit строк
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	b.partial = append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i < 0 {
+			break
+		}
+		b.lines = append(b.lines, string(b.partial[:i]))
+		b.partial = b.partial[i+1:]
+	}
+	if len(b.lines) > b.limit {
+		b.lines = append([]string(nil), b.lines[len(b.lines)-b.limit:]...)
+	}
+	onChange := b.onChange
+	b.mu.Unlock()
+
+	if onChange != nil {
+		onChange()
+	}
+	return len(p), nil
+}
+
+// Lines возвращает копию накопленных строк
+func (b *LogBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.lines...)
+}
+
+// OnChange задает функцию, вызываемую после каждой записи в буфер
+func (b *LogBuffer) OnChange(callback func()) {
+	b.mu.Lock()
+	b.onChange = callback
+	b.mu.Unlock()
+}
+
+// rotatingFile - файл журнала, который переименовывается в .1, .2, ... при превышении размера
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+func openRotatingFile(path string) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{path: path}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size+int64(len(p)) > logMaxSize && r.size > 0 {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate сдвигает старые файлы журнала и начинает новый
+func (r *rotatingFile) rotate() error {
+	r.file.Close()
+	os.Remove(fmt.Sprintf("%s.%d", r.path, logMaxBackups))
+	for i := logMaxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return r.open()
+}
+
+// LogFilePath возвращает путь к файлу журнала: он лежит рядом с config.json
+func LogFilePath() string {
+	return filepath.Join(filepath.Dir(ConfigPath()), logFileName)
+}
+
+// ignoreErrors - writer, который не сообщает об ошибках записи, чтобы недоступная консоль
+// не мешала остальным журналам
+type ignoreErrors struct {
+	w io.Writer
+}
+
+func (i ignoreErrors) Write(p []byte) (int, error) {
+	i.w.Write(p)
+	return len(p), nil
+}
+
+// parseLogLevel переводит уровень из конфигурации (debug, info, warn, error) в slog.Level
+func parseLogLevel(level string) (slog.Level, error) {
+	var result slog.Level
+	if level == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := result.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return slog.LevelInfo, fmt.Errorf("%s", T("error.logLevel", "Level", level))
+	}
+	return result, nil
+}
+
+// InitLogging направляет журнал в файл рядом с конфигурацией (с ротацией), в stderr и в буфер LogLines.
+// Уровень берется из MBC_LOG_LEVEL или поля log_level конфигурации.
+func InitLogging(config *Config) error {
+	levelName := os.Getenv(envPrefix + "LOG_LEVEL")
+	if levelName == "" && config != nil {
+		levelName = config.LogLevel
+	}
+	level, err := parseLogLevel(levelName)
+	if err != nil {
+		return err
+	}
+
+	// stderr последним и без ошибок: io.MultiWriter останавливается на первой ошибке,
+	// а в сборке -H windowsgui stderr недействителен
+	writers := []io.Writer{LogLines}
+	file, fileErr := openRotatingFile(LogFilePath())
+	if fileErr == nil {
+		writers = append(writers, file)
+	}
+	writers = append(writers, ignoreErrors{os.Stderr})
+
+	Logger = slog.New(slog.NewTextHandler(io.MultiWriter(writers...), &slog.HandlerOptions{Level: level}))
+	slog.SetDefault(Logger)
+	if fileErr != nil {
+		Logger.Warn("log file is not available", "path", LogFilePath(), "error", fileErr)
+		return nil
+	}
+	Logger.Debug("logging initialized", "path", LogFilePath(), "level", level.String())
+	return nil
+}
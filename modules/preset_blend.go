@@ -0,0 +1,110 @@
+package modules
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// BlendPresets строит промежуточный пресет между a и b для переходной зоны: t=0 - пресет a, t=1 - b.
+// Аргументы - имена локальных пресетов или пути к файлам, как в ComparePresets.
+// Числовые величины (пределы высот, вероятность, множители UV, порог крутизны) интерполируются
+// линейно, целые округляются. Дискретные значения (текстуры, действие на склоне, логические маски)
+// и неизвестные ключи берутся из ближайшего пресета. Ключи и секции, которые есть только в одном
+// пресете, копируются из него при любом t, чтобы смешивание их не теряло.
+// Смешиваются только секции пресетов биомов; кисти растительности пропускаются.
+// Имя в метаданных не задается: его выбирает вызывающий перед сохранением.
+func BlendPresets(a, b string, t float64) (*PresetFile, error) {
+	if math.IsNaN(t) || t < 0 || t > 1 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidValue, T("blend.badFactor", "Value", t))
+	}
+	blocksA, err := loadPresetForCompare(a)
+	if err != nil {
+		return nil, err
+	}
+	blocksB, err := loadPresetForCompare(b)
+	if err != nil {
+		return nil, err
+	}
+
+	byRefA, refsA := presetSections(blocksA)
+	byRefB, refsB := presetSections(blocksB)
+	nearer, farther := byRefA, byRefB
+	if t > 0.5 {
+		nearer, farther = byRefB, byRefA
+	}
+
+	refs := refsA
+	for _, ref := range refsB {
+		if _, exists := byRefA[ref]; !exists {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].less(refs[j]) })
+
+	file := &PresetFile{
+		FormatVersion: presetFormatVersion,
+		Metadata: PresetMetadata{
+			Description: fmt.Sprintf("%s → %s, t=%s", a, b, strconv.FormatFloat(t, 'f', -1, 64)),
+			Source:      "blend",
+		},
+	}
+	for _, ref := range refs {
+		if !slices.Contains(BiomeFamily.Kinds, ref.Kind) {
+			continue
+		}
+		near, inNear := nearer[ref]
+		far := farther[ref]
+		if !inNear {
+			// Секция есть только в дальнем пресете
+			near, far = far, PresetBlock{}
+		}
+
+		keys := comparedKeys(ref.Kind, near, far)
+		values := make(map[string]string, len(keys))
+		for _, key := range keys {
+			nearValue, inNearKey := near.Values[key]
+			farValue, inFarKey := far.Values[key]
+			switch {
+			case !inNearKey:
+				values[key] = farValue
+			case !inFarKey:
+				values[key] = nearValue
+			default:
+				values[key] = blendValue(ref.Kind.spec(key), byRefA[ref].Values[key], byRefB[ref].Values[key], nearValue, t)
+			}
+		}
+		file.Slots = append(file.Slots, PresetSection{
+			Path:   ref.Kind.suffix(ref.ID),
+			Values: PresetValues{Keys: keys, Values: values},
+		})
+	}
+
+	if err := validatePreset(file.Metadata.Description, file.blocks(), BiomeFamily.Kinds); err != nil {
+		return nil, err
+	}
+	Logger.Debug("presets blended", "a", a, "b", b, "t", t, "sections", len(file.Slots))
+	return file, nil
+}
+
+// blendValue смешивает значение ключа, которое есть в обоих пресетах: valueA и valueB - значения
+// пресетов a и b, near - значение ближайшего из них
+func blendValue(spec *keySpec, valueA, valueB, near string, t float64) string {
+	if spec == nil || spec.Type == valueBool || spec.Discrete {
+		return near
+	}
+	x, errA := strconv.ParseFloat(valueA, 64)
+	y, errB := strconv.ParseFloat(valueB, 64)
+	if errA != nil || errB != nil {
+		return near
+	}
+
+	value := x + (y-x)*t
+	if spec.Type == valueInt {
+		return strconv.Itoa(int(math.Round(value)))
+	}
+	// Четырех знаков хватает для всех величин слота и не дает хвостов вроде 0.30000000000000004
+	return strconv.FormatFloat(math.Round(value*1e4)/1e4, 'f', -1, 64)
+}
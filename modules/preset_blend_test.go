@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"errors"
+	"maps"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestPreset сохраняет пресет собственного формата с секциями path → значения и возвращает путь
+func writeTestPreset(t *testing.T, dir, name string, sections map[string]map[string]string) string {
+	t.Helper()
+	blocks := make(map[string]PresetBlock, len(sections))
+	for path, values := range sections {
+		header := sectionHeader(path)
+		blocks[header] = PresetBlock{Header: header, Values: values}
+	}
+	path := filepath.Join(dir, name+".json")
+	if err := savePresetFile(path, newPresetFile(name, blocks)); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBlendPresets(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestPreset(t, dir, "meadow", map[string]map[string]string{
+		"Tools/TerrainEdit/MaterialPairSlot1": {"HeightLowLimit": "0", "Probability": "100", "SelectedHorizontalTexture": "3", "VerticalMask": "false"},
+		"Tools/TerrainEdit/MaterialPairSlot2": {"Probability": "50"},
+	})
+	b := writeTestPreset(t, dir, "rocks", map[string]map[string]string{
+		"Tools/TerrainEdit/MaterialPairSlot1": {"HeightLowLimit": "100", "Probability": "50", "SelectedHorizontalTexture": "7", "VerticalMask": "true"},
+		"Tools/TerrainEdit/MaterialPairSlot3": {"Probability": "20"},
+	})
+
+	// Слоты 2 и 3 есть только в одном пресете и при любом t копируются из него
+	onlyA := map[string]string{"Probability": "50"}
+	onlyB := map[string]string{"Probability": "20"}
+	tests := []struct {
+		t     float64
+		slot1 map[string]string
+	}{
+		{0, map[string]string{"HeightLowLimit": "0", "Probability": "100", "SelectedHorizontalTexture": "3", "VerticalMask": "false"}},
+		{0.5, map[string]string{"HeightLowLimit": "50", "Probability": "75", "SelectedHorizontalTexture": "3", "VerticalMask": "false"}},
+		{0.75, map[string]string{"HeightLowLimit": "75", "Probability": "62.5", "SelectedHorizontalTexture": "7", "VerticalMask": "true"}},
+		{1, map[string]string{"HeightLowLimit": "100", "Probability": "50", "SelectedHorizontalTexture": "7", "VerticalMask": "true"}},
+	}
+	for _, tt := range tests {
+		file, err := BlendPresets(a, b, tt.t)
+		if err != nil {
+			t.Fatalf("t=%v: %v", tt.t, err)
+		}
+		slots := make(map[string]map[string]string)
+		for _, section := range file.Slots {
+			slots[section.Path] = section.Values.Values
+		}
+		want := map[string]map[string]string{
+			"Tools/TerrainEdit/MaterialPairSlot1": tt.slot1,
+			"Tools/TerrainEdit/MaterialPairSlot2": onlyA,
+			"Tools/TerrainEdit/MaterialPairSlot3": onlyB,
+		}
+		if len(slots) != len(want) {
+			t.Errorf("t=%v: секции %v", tt.t, slots)
+		}
+		for path, values := range want {
+			if !maps.Equal(slots[path], values) {
+				t.Errorf("t=%v: %s = %v, ожидалось %v", tt.t, path, slots[path], values)
+			}
+		}
+	}
+}
+
+func TestBlendPresetsBadFactor(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestPreset(t, dir, "meadow", map[string]map[string]string{"Tools/TerrainEdit/MaterialPairSlot1": {"Probability": "100"}})
+	for _, factor := range []float64{-0.1, 1.5} {
+		if _, err := BlendPresets(a, a, factor); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("t=%v: ошибка %v, ожидалась ErrInvalidValue", factor, err)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Сколько последних примененных пресетов хранится в профиле
+const recentPresetsLimit = 10
+
+// PresetInfo - локальный пресет с метаданными всех его семейств для поиска и фильтров
+type PresetInfo struct {
+	Name        string
+	Description string
+	Authors     []string
+	Tags        []string
+	Sources     []string
+}
+
+// PresetFilter - условия отбора пресетов; пустые поля не ограничивают выбор
+type PresetFilter struct {
+	Query         string // Нечеткий поиск по имени: буквы запроса должны встречаться в имени по порядку
+	Tag           string
+	Author        string
+	Source        string
+	FavoritesOnly bool
+}
+
+// LoadPresetCatalog возвращает локальные пресеты по алфавиту с метаданными.
+// Метаданные есть только у собственного формата; у остальных форматов известно лишь имя.
+func LoadPresetCatalog() ([]PresetInfo, error) {
+	names, err := FetchAvailablePresets()
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := make([]PresetInfo, 0, len(names))
+	for _, name := range names {
+		info := PresetInfo{Name: name}
+		for _, family := range presetFamiliesOf(name) {
+			metadata, ok := readPresetMetadata(family.Path(name))
+			if !ok {
+				continue
+			}
+			if info.Description == "" {
+				info.Description = metadata.Description
+			}
+			info.Authors = appendUnique(info.Authors, metadata.Author)
+			info.Tags = appendUnique(info.Tags, metadata.Tags...)
+			info.Sources = appendUnique(info.Sources, metadata.Source)
+		}
+		catalog = append(catalog, info)
+	}
+	return catalog, nil
+}
+
+// readPresetMetadata читает метаданные файла собственного формата без проверки секций;
+// для остальных форматов и нечитаемых файлов возвращает false
+func readPresetMetadata(path string) (PresetMetadata, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+		return PresetMetadata{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PresetMetadata{}, false
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil || !isNativePreset(&document) {
+		return PresetMetadata{}, false
+	}
+	var metadata PresetMetadata
+	if node := mappingValue(document.Content[0], "metadata"); node == nil || node.Decode(&metadata) != nil {
+		return PresetMetadata{}, false
+	}
+	return metadata, true
+}
+
+// appendUnique добавляет непустые значения, которых еще нет в списке
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if value != "" && !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// PresetCatalogValues возвращает все теги, авторов и источники каталога по алфавиту - варианты фильтров
+func PresetCatalogValues(catalog []PresetInfo) (tags, authors, sources []string) {
+	for _, info := range catalog {
+		tags = appendUnique(tags, info.Tags...)
+		authors = appendUnique(authors, info.Authors...)
+		sources = appendUnique(sources, info.Sources...)
+	}
+	sort.Strings(tags)
+	sort.Strings(authors)
+	sort.Strings(sources)
+	return tags, authors, sources
+}
+
+// FilterPresets отбирает пресеты каталога по фильтру. Без запроса порядок каталога сохраняется,
+// с запросом лучшие совпадения идут первыми.
+func FilterPresets(catalog []PresetInfo, filter PresetFilter, favorites []string) []PresetInfo {
+	type match struct {
+		info  PresetInfo
+		score int
+	}
+	var matches []match
+	for _, info := range catalog {
+		if filter.FavoritesOnly && !slices.Contains(favorites, info.Name) ||
+			filter.Tag != "" && !slices.Contains(info.Tags, filter.Tag) ||
+			filter.Author != "" && !slices.Contains(info.Authors, filter.Author) ||
+			filter.Source != "" && !slices.Contains(info.Sources, filter.Source) {
+			continue
+		}
+		score, ok := fuzzyScore(info.Name, filter.Query)
+		if !ok {
+			continue
+		}
+		matches = append(matches, match{info, score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	result := make([]PresetInfo, len(matches))
+	for i, m := range matches {
+		result[i] = m.info
+	}
+	return result
+}
+
+// fuzzyScore проверяет, что символы запроса встречаются в имени по порядку без учета регистра,
+// и оценивает совпадение: подряд идущие символы, начало имени и начала слов ценятся выше
+func fuzzyScore(name, query string) (int, bool) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return 0, true
+	}
+
+	runes := []rune(name)
+	lower := []rune(strings.ToLower(name))
+	score := 0
+	previous := -2
+	position := 0
+	for _, q := range query {
+		for position < len(lower) && lower[position] != q {
+			position++
+		}
+		if position == len(lower) {
+			return 0, false
+		}
+		switch {
+		case position == 0:
+			score += 3
+		case position == previous+1:
+			score += 2
+		case !unicode.IsLetter(runes[position-1]) || unicode.IsUpper(runes[position]) && unicode.IsLower(runes[position-1]):
+			score += 2
+		default:
+			score++
+		}
+		previous = position
+		position++
+	}
+	// При равных совпадениях короткие имена ближе к запросу
+	return score*100 - len(lower), true
+}
+
+// IsFavorite сообщает, отмечен ли пресет звездой в профиле
+func (p *Profile) IsFavorite(presetName string) bool {
+	return slices.Contains(p.Favorites, presetName)
+}
+
+// ToggleFavorite ставит или снимает звезду пресета в профиле и сохраняет конфигурацию.
+// Возвращает новое состояние.
+func ToggleFavorite(profile *Profile, presetName string) (bool, error) {
+	favorite := !profile.IsFavorite(presetName)
+	err := updateProfile(profile, func(p *Profile) {
+		if favorite {
+			p.Favorites = appendUnique(p.Favorites, presetName)
+		} else {
+			p.Favorites = slices.DeleteFunc(p.Favorites, func(name string) bool { return name == presetName })
+		}
+	})
+	return favorite, err
+}
+
+// rememberApplied переносит примененные пресеты в начало списка недавних профиля. Пресеты, которых
+// нет в папках (несохраненный результат смешивания), не запоминаются: выбрать их из списка нельзя.
+// Ошибка сохранения только записывается в журнал: пресет к этому моменту уже применен.
+func rememberApplied(profile *Profile, presetNames ...string) {
+	presetNames = slices.DeleteFunc(slices.Clone(presetNames), func(name string) bool { return !PresetExists(name) })
+	if len(presetNames) == 0 {
+		return
+	}
+	err := updateProfile(profile, func(p *Profile) {
+		recent := slices.Clone(presetNames)
+		for _, name := range p.Recent {
+			if !slices.Contains(recent, name) {
+				recent = append(recent, name)
+			}
+		}
+		if len(recent) > recentPresetsLimit {
+			recent = recent[:recentPresetsLimit]
+		}
+		p.Recent = recent
+	})
+	if err != nil {
+		Logger.Warn("recent presets not saved", "profile", profile.Name, "error", err)
+	}
+}
+
+// updateProfile меняет профиль в config.json и ту же правку применяет к профилю в памяти
+func updateProfile(profile *Profile, update func(*Profile)) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("%s: %v", T("error.configLoad"), err)
+	}
+	stored := config.Profile(profile.Name)
+	if stored == nil {
+		return fmt.Errorf("%s", T("error.profileNotFound", "Name", profile.Name))
+	}
+	update(stored)
+	if err := SaveConfig(config); err != nil {
+		return fmt.Errorf("%s: %v", T("error.configSave"), err)
+	}
+	update(profile)
+	return nil
+}
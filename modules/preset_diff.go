@@ -0,0 +1,163 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KeyComparison - значения одного ключа в двух пресетах; In* ложны, если ключа в пресете нет
+type KeyComparison struct {
+	Key     string
+	A       string
+	B       string
+	InA     bool
+	InB     bool
+	Differs bool
+}
+
+// SlotComparison - сравнение одной секции (слота или инструмента) двух пресетов
+type SlotComparison struct {
+	Section string // Путь секции внутри мира, например Tools/TerrainEdit/MaterialPairSlot3
+	InA     bool
+	InB     bool
+	Keys    []KeyComparison
+}
+
+// Differences возвращает ключи с разными значениями
+func (s SlotComparison) Differences() []KeyComparison {
+	var differences []KeyComparison
+	for _, key := range s.Keys {
+		if key.Differs {
+			differences = append(differences, key)
+		}
+	}
+	return differences
+}
+
+// Summary возвращает однострочный итог по секции на текущем языке
+func (s SlotComparison) Summary(nameA, nameB string) string {
+	switch {
+	case !s.InB:
+		return T("compare.onlyIn", "Section", s.Section, "Preset", nameA)
+	case !s.InA:
+		return T("compare.onlyIn", "Section", s.Section, "Preset", nameB)
+	}
+	differences := s.Differences()
+	if len(differences) == 0 {
+		return T("compare.same", "Section", s.Section)
+	}
+	keys := make([]string, len(differences))
+	for i, key := range differences {
+		keys[i] = key.Key
+	}
+	return T("compare.differs", "Section", s.Section, "Count", len(differences), "Keys", strings.Join(keys, ", "))
+}
+
+// PresetComparison - сравнение двух пресетов по секциям в порядке секций мира
+type PresetComparison struct {
+	A     string
+	B     string
+	Slots []SlotComparison
+}
+
+// Lines возвращает отчет: итог по каждой секции и различающиеся значения под ним.
+// С all в отчет попадают и совпадающие секции.
+func (c *PresetComparison) Lines(all bool) []string {
+	var lines []string
+	for _, slot := range c.Slots {
+		differences := slot.Differences()
+		if !all && slot.InA && slot.InB && len(differences) == 0 {
+			continue
+		}
+		lines = append(lines, slot.Summary(c.A, c.B))
+		for _, key := range differences {
+			lines = append(lines, fmt.Sprintf("  %s: %s → %s", key.Key, DisplayValue(key.A, key.InA), DisplayValue(key.B, key.InB)))
+		}
+	}
+	return lines
+}
+
+// ComparePresets сравнивает два пресета. Аргумент - имя локального пресета (все семейства с этим
+// именем) или путь к файлу пресета любого поддерживаемого формата.
+func ComparePresets(a, b string) (*PresetComparison, error) {
+	blocksA, err := loadPresetForCompare(a)
+	if err != nil {
+		return nil, err
+	}
+	blocksB, err := loadPresetForCompare(b)
+	if err != nil {
+		return nil, err
+	}
+	return comparePresetBlocks(a, b, blocksA, blocksB), nil
+}
+
+// loadPresetForCompare читает пресет по имени или по пути к файлу; переменные шаблонов
+// получают значения по умолчанию из пресета
+func loadPresetForCompare(nameOrPath string) (map[string]PresetBlock, error) {
+	if isPresetFile(nameOrPath) && FileExists(nameOrPath) {
+		return loadPresetTemplate(nameOrPath, nil)
+	}
+	return loadPresetFamilies(nameOrPath, nil)
+}
+
+// comparePresetBlocks сопоставляет секции двух пресетов по виду и идентификатору: заголовки
+// могут ссылаться на разные проекты. Ключи идут в порядке таблицы вида, затем остальные по алфавиту.
+func comparePresetBlocks(nameA, nameB string, blocksA, blocksB map[string]PresetBlock) *PresetComparison {
+	byRefA, refsA := presetSections(blocksA)
+	byRefB, refsB := presetSections(blocksB)
+
+	refs := refsA
+	for _, ref := range refsB {
+		if _, exists := byRefA[ref]; !exists {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].less(refs[j]) })
+
+	comparison := &PresetComparison{A: nameA, B: nameB}
+	for _, ref := range refs {
+		blockA, inA := byRefA[ref]
+		blockB, inB := byRefB[ref]
+		slot := SlotComparison{Section: ref.Kind.suffix(ref.ID), InA: inA, InB: inB}
+
+		for _, key := range comparedKeys(ref.Kind, blockA, blockB) {
+			valueA, keyInA := blockA.Values[key]
+			valueB, keyInB := blockB.Values[key]
+			slot.Keys = append(slot.Keys, KeyComparison{
+				Key:     key,
+				A:       valueA,
+				B:       valueB,
+				InA:     keyInA,
+				InB:     keyInB,
+				Differs: keyInA != keyInB || !sameValue(valueA, valueB),
+			})
+		}
+		comparison.Slots = append(comparison.Slots, slot)
+	}
+	return comparison
+}
+
+// comparedKeys возвращает объединение ключей двух блоков: известные в порядке вида, затем прочие по алфавиту
+func comparedKeys(kind *SectionKind, blocks ...PresetBlock) []string {
+	present := make(map[string]bool)
+	for _, block := range blocks {
+		for key := range block.Values {
+			present[key] = true
+		}
+	}
+
+	var keys []string
+	for _, key := range kind.keyOrder() {
+		if present[key] {
+			keys = append(keys, key)
+			delete(present, key)
+		}
+	}
+	var extra []string
+	for key := range present {
+		extra = append(extra, key)
+	}
+	sort.Strings(extra)
+	return append(keys, extra...)
+}
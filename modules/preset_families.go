@@ -0,0 +1,167 @@
+package modules
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PresetFamily - семейство пресетов со своими источниками, локальной папкой и видами секций.
+// Пресеты разных семейств с одинаковым именем применяются вместе, за один проход по файлу сессий.
+type PresetFamily struct {
+	Name          string
+	Folder        string         // Локальная папка с пресетами (JSON, YAML или TXT)
+	DefaultSource string         // Папка репозитория на GitHub, если в профиле источники не заданы
+	RepoFolder    string         // Папка семейства в репозитории пресетов, куда пишет публикация
+	Kinds         []*SectionKind // Виды секций, которые может содержать пресет семейства
+}
+
+// Пресеты материалов биома (biomebrushes) и кистей растительности (foliagebrushes)
+var (
+	BiomeFamily = &PresetFamily{
+		Name:          "biome",
+		Folder:        presetsFolder,
+		DefaultSource: githubRepoAPI,
+		RepoFolder:    "biomebrushes",
+		Kinds:         []*SectionKind{materialPairKind, terrainToolKind},
+	}
+	FoliageFamily = &PresetFamily{
+		Name:          "foliage",
+		Folder:        foliagePresetsFolder,
+		DefaultSource: githubFoliageRepoAPI,
+		RepoFolder:    "foliagebrushes",
+		Kinds:         []*SectionKind{foliageBrushKind},
+	}
+)
+
+// Все семейства в порядке применения
+var PresetFamilies = []*PresetFamily{BiomeFamily, FoliageFamily}
+
+// Path возвращает путь к локальному файлу пресета семейства. Если есть файлы нескольких форматов,
+// выбирается первый по presetExtensions; если файла нет - путь к JSON, куда пишет загрузка.
+func (f *PresetFamily) Path(presetName string) string {
+	for _, ext := range presetExtensions {
+		path := filepath.Join(f.Folder, presetName+ext)
+		if FileExists(path) {
+			return path
+		}
+	}
+	return f.NativePath(presetName)
+}
+
+// NativePath возвращает путь к файлу пресета семейства в собственном формате JSON
+func (f *PresetFamily) NativePath(presetName string) string {
+	return filepath.Join(f.Folder, presetName+".json")
+}
+
+// available возвращает имена пресетов в локальной папке семейства
+func (f *PresetFamily) available() ([]string, error) {
+	files, err := ioutil.ReadDir(f.Folder)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %v", T("error.presetsFolderRead"), err)
+	}
+
+	var presets []string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if file.IsDir() || !isPresetFile(file.Name()) {
+			continue
+		}
+		name := strings.TrimSuffix(file.Name(), ext)
+		if !seen[name] {
+			seen[name] = true
+			presets = append(presets, name)
+		}
+	}
+	return presets, nil
+}
+
+// sources возвращает источники семейства из профиля или папку репозитория по умолчанию
+func (f *PresetFamily) sources(profile *Profile) []string {
+	switch f {
+	case BiomeFamily:
+		return profile.Sources()
+	case FoliageFamily:
+		if len(profile.FoliageSources) > 0 {
+			return profile.FoliageSources
+		}
+	}
+	return []string{f.DefaultSource}
+}
+
+// FetchPresets загружает пресеты всех семейств из источников профиля.
+// Локальные пресеты с правками не перезаписываются, см. UpdatePresets.
+func FetchPresets(profile *Profile) error {
+	_, err := UpdatePresets(profile, false)
+	return err
+}
+
+// UpdatePresets загружает пресеты всех семейств из источников профиля. Пресеты, измененные
+// после загрузки или созданные пользователем, перезаписываются только с overwrite.
+// Ошибка загрузки растительности из источника по умолчанию только записывается в журнал:
+// папка foliagebrushes есть не во всех репозиториях.
+func UpdatePresets(profile *Profile, overwrite bool) (FetchResult, error) {
+	result, err := FetchAndConvertBiomeBrushes(BiomeFamily.sources(profile), overwrite)
+	if err != nil {
+		return result, err
+	}
+	foliage, err := FetchAndConvertFoliageBrushes(FoliageFamily.sources(profile), overwrite)
+	result.Updated = append(result.Updated, foliage.Updated...)
+	result.Kept = append(result.Kept, foliage.Kept...)
+	if err != nil {
+		if len(profile.FoliageSources) > 0 {
+			return result, err
+		}
+		Logger.Warn("foliage presets not fetched", "error", err)
+	}
+	return result, nil
+}
+
+// PresetExists сообщает, есть ли локально пресет с таким именем хотя бы в одном семействе
+func PresetExists(presetName string) bool {
+	return len(presetFamiliesOf(presetName)) > 0
+}
+
+// presetFamiliesOf возвращает семейства, у которых есть локальный пресет с таким именем
+func presetFamiliesOf(presetName string) []*PresetFamily {
+	var families []*PresetFamily
+	for _, family := range PresetFamilies {
+		if FileExists(family.Path(presetName)) {
+			families = append(families, family)
+		}
+	}
+	return families
+}
+
+// loadPresetFamilies читает и проверяет пресеты всех семейств с указанным именем
+// и объединяет их блоки для применения за один проход. Переменные шаблонов берутся из values,
+// затем из значений по умолчанию в пресете.
+func loadPresetFamilies(presetName string, values map[string]string) (map[string]PresetBlock, error) {
+	families := presetFamiliesOf(presetName)
+	if len(families) == 0 {
+		return nil, fmt.Errorf("%s", T("error.presetNotFound", "Name", presetName))
+	}
+
+	blocks := make(map[string]PresetBlock)
+	for _, family := range families {
+		path := family.Path(presetName)
+		familyBlocks, err := loadPresetTemplate(path, values)
+		if err != nil {
+			return nil, err
+		}
+		if err := validatePreset(path, familyBlocks, family.Kinds); err != nil {
+			return nil, err
+		}
+		for header, block := range familyBlocks {
+			blocks[header] = block
+		}
+		Logger.Debug("preset family loaded", "family", family.Name, "path", path, "blocks", len(familyBlocks))
+	}
+	return blocks, nil
+}
@@ -0,0 +1,308 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Версия собственного формата пресетов, которую пишет программа
+const presetFormatVersion = 1
+
+// Расширения файлов пресетов в порядке предпочтения, если в папке есть несколько файлов с одним именем:
+// собственный формат (JSON, YAML), пронумерованный JSON из репозитория и старый TXT
+var presetExtensions = []string{".json", ".yaml", ".yml", ".txt"}
+
+// PresetFile - пресет в собственном формате (JSON или YAML), схема - schemas/preset.schema.json
+type PresetFile struct {
+	FormatVersion int                       `json:"formatVersion" yaml:"formatVersion"`
+	Metadata      PresetMetadata            `json:"metadata" yaml:"metadata"`
+	Extends       string                    `json:"extends,omitempty" yaml:"extends,omitempty"` // Имя родительского пресета
+	Variables     map[string]PresetVariable `json:"variables,omitempty" yaml:"variables,omitempty"`
+	Slots         []PresetSection           `json:"slots" yaml:"slots"`
+}
+
+// PresetMetadata - описание пресета; на применение не влияет
+type PresetMetadata struct {
+	Name        string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Author      string   `json:"author,omitempty" yaml:"author,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Source      string   `json:"source,omitempty" yaml:"source,omitempty"` // Откуда взят пресет (адрес, файл)
+
+	// Зерно и отклонения, с которыми вариант получен из исходного пресета (VaryPreset)
+	Seed   *int64             `json:"seed,omitempty" yaml:"seed,omitempty"`
+	Jitter map[string]float64 `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+}
+
+// PresetSection - одна секция пресета: путь внутри мира (часть заголовка после .w2w/)
+// и значения ключей с типами (логические, числа, строки)
+type PresetSection struct {
+	Path   string       `json:"path" yaml:"path"`
+	Values PresetValues `json:"values" yaml:"values"`
+}
+
+// PresetValues - значения ключей секции в порядке, как в файле
+type PresetValues struct {
+	Keys   []string
+	Values map[string]string
+}
+
+// UnmarshalYAML читает объект значений, сохраняя порядок ключей; значения хранятся строками, как в файле сессий
+func (v *PresetValues) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s", T("preset.valuesNotObject", "Line", node.Line))
+	}
+	v.Values = make(map[string]string)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind != yaml.ScalarNode || value.Tag == "!!null" {
+			return fmt.Errorf("%s", T("preset.valueNotScalar", "Key", key.Value, "Line", value.Line))
+		}
+		text := value.Value
+		// Дробные числа в экспоненциальной записи (1e2) редактор не читает
+		if value.Tag == "!!float" {
+			if number, err := strconv.ParseFloat(text, 64); err == nil {
+				text = strconv.FormatFloat(number, 'f', -1, 64)
+			}
+		}
+		if _, exists := v.Values[key.Value]; !exists {
+			v.Keys = append(v.Keys, key.Value)
+		}
+		v.Values[key.Value] = text
+	}
+	return nil
+}
+
+// MarshalJSON пишет значения объектом в исходном порядке ключей: логические значения и числа -
+// без кавычек, остальное - строками
+func (v PresetValues) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range v.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+
+		value := v.Values[key]
+		if number, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(number) && !math.IsInf(number, 0) {
+			buf.WriteString(strconv.FormatFloat(number, 'f', -1, 64))
+		} else if value == "true" || value == "false" {
+			buf.WriteString(value)
+		} else {
+			text, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(text)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// newPresetFile собирает пресет собственного формата из блоков в порядке секций мира.
+// Блоки с заголовками неизвестных видов пропускаются: применить их все равно нельзя.
+func newPresetFile(name string, blocks map[string]PresetBlock) *PresetFile {
+	file := &PresetFile{FormatVersion: presetFormatVersion, Metadata: PresetMetadata{Name: name}}
+	byRef, refs := presetSections(blocks)
+	for _, ref := range refs {
+		block := byRef[ref]
+		file.Slots = append(file.Slots, PresetSection{
+			Path:   ref.Kind.suffix(ref.ID),
+			Values: PresetValues{Keys: block.keys(), Values: block.Values},
+		})
+	}
+	return file
+}
+
+// savePresetFile записывает пресет собственного формата в JSON
+func savePresetFile(path string, file *PresetFile) error {
+	data, err := encodePresetFile(file)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("%s: %v", T("error.writeFile"), err)
+	}
+	return nil
+}
+
+// encodePresetFile возвращает содержимое файла пресета собственного формата, как его пишет savePresetFile
+func encodePresetFile(file *PresetFile) ([]byte, error) {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// sectionHeader возвращает заголовок секции собственного формата; проект и мир в нем не указываются,
+// при применении секция все равно переносится в мир профиля
+func sectionHeader(path string) string {
+	return "[/" + strings.Trim(path, "/[]") + "]"
+}
+
+// numberedHeader возвращает заголовок секции из поля path пронумерованного JSON (путь в двойных скобках)
+func numberedHeader(path string) string {
+	return replaceDoubleBrackets("[" + replaceDoubleBrackets(path) + "]")
+}
+
+// LoadPresetBlocks читает блоки пресета из файла любого поддерживаемого формата:
+// собственного JSON/YAML, пронумерованного JSON из репозитория biomebrushes или старого TXT.
+// Если пресет наследует другой (extends), его значения накладываются на значения родителей.
+func LoadPresetBlocks(path string) (map[string]PresetBlock, error) {
+	chain, err := loadPresetChain(path)
+	if err != nil {
+		return nil, err
+	}
+	return mergePresetChain(chain), nil
+}
+
+// readPresetLayer читает блоки одного файла пресета без учета родителей
+func readPresetLayer(path string) (presetLayer, error) {
+	layer := presetLayer{Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), Path: path}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".txt":
+		blocks, err := ParsePresetBlocks(path)
+		layer.Blocks = blocks
+		return layer, err
+	case ".yaml", ".yml", ".json":
+	default:
+		return layer, fmt.Errorf("%s", T("error.presetFormat", "Path", path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return layer, fmt.Errorf("%s: %w", T("error.presetOpen"), err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return layer, fmt.Errorf("%s: %v", T("error.presetParse", "Path", path), err)
+	}
+	if isNativePreset(&document) {
+		file, err := decodeNativePreset(path, &document)
+		if err != nil {
+			return layer, err
+		}
+		layer.Blocks = file.blocks()
+		layer.Extends = file.Extends
+		layer.Variables = file.Variables
+		return layer, nil
+	}
+
+	// Без formatVersion JSON считается выгрузкой из репозитория: {"1": {"path": ..., ключи}, ...}
+	var numbered map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &numbered); err != nil {
+		return layer, fmt.Errorf("%s: %v", T("error.presetParse", "Path", path), err)
+	}
+	layer.Blocks = make(map[string]PresetBlock)
+	for _, block := range numberedJSONBlocks(numbered) {
+		layer.Blocks[block.Header] = block
+	}
+	Logger.Debug("preset parsed", "path", path, "format", "numbered", "blocks", len(layer.Blocks))
+	return layer, nil
+}
+
+// isNativePreset сообщает, есть ли в документе поле formatVersion верхнего уровня
+func isNativePreset(document *yaml.Node) bool {
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return false
+	}
+	return mappingValue(document.Content[0], "formatVersion") != nil
+}
+
+// decodeNativePreset проверяет документ по схеме preset.schema.json и разбирает его
+func decodeNativePreset(path string, document *yaml.Node) (*PresetFile, error) {
+	// Для схемы документ переводится в JSON, чтобы проверка была одинаковой для JSON и YAML
+	var raw interface{}
+	if err := document.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.presetParse", "Path", path), err)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.presetParse", "Path", path), err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.presetParse", "Path", path), err)
+	}
+	if err := validateAgainstSchema("preset.schema.json", generic); err != nil {
+		return nil, fmt.Errorf("%s: %w", T("error.presetInvalid", "Path", path), err)
+	}
+
+	var file PresetFile
+	if err := document.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.presetParse", "Path", path), err)
+	}
+	Logger.Debug("preset parsed", "path", path, "format", "native", "version", file.FormatVersion, "blocks", len(file.Slots))
+	return &file, nil
+}
+
+// blocks переводит секции собственного формата в блоки пресета
+func (f *PresetFile) blocks() map[string]PresetBlock {
+	blocks := make(map[string]PresetBlock)
+	for _, section := range f.Slots {
+		header := sectionHeader(section.Path)
+		blocks[header] = PresetBlock{Header: header, Keys: section.Values.Keys, Values: section.Values.Values}
+	}
+	return blocks
+}
+
+// numberedJSONBlocks переводит пронумерованный JSON из репозитория в блоки в порядке номеров.
+// Известные ключи идут в порядке таблицы вида секции, неизвестные - по алфавиту после них.
+func numberedJSONBlocks(jsonData map[string]map[string]interface{}) []PresetBlock {
+	var blocks []PresetBlock
+	for i := 1; i <= len(jsonData); i++ { // Гарантируем порядок обработки
+		block := jsonData[fmt.Sprintf("%d", i)]
+
+		// По заголовку блока определяется вид секции (по умолчанию - слоты материалов)
+		kind := materialPairKind
+		presetBlock := PresetBlock{Values: make(map[string]string)}
+		if path, exists := block["path"]; exists {
+			presetBlock.Header = numberedHeader(fmt.Sprintf("%v", path))
+			if ref, ok := kindOf(presetBlock.Header); ok {
+				kind = ref.Kind
+			}
+		}
+
+		add := func(key string) {
+			presetBlock.Keys = append(presetBlock.Keys, key)
+			presetBlock.Values[key] = replaceDoubleBrackets(fmt.Sprintf("%v", block[key]))
+		}
+		for _, key := range kind.keyOrder() {
+			if _, exists := block[key]; exists {
+				add(key)
+			}
+		}
+
+		// Неизвестные ключи (например, поля новых версий REDkit) не теряем; порядок в JSON не сохраняется
+		var extraKeys []string
+		for key := range block {
+			if key != "path" && !kind.isKnown(key) {
+				extraKeys = append(extraKeys, key)
+			}
+		}
+		sort.Strings(extraKeys)
+		for _, key := range extraKeys {
+			add(key)
+		}
+		blocks = append(blocks, presetBlock)
+	}
+	return blocks
+}
@@ -0,0 +1,225 @@
+package modules
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// GeneratorTexture - пара текстур слоя: для пологих участков и для склонов
+type GeneratorTexture struct {
+	Horizontal int
+	Vertical   int
+}
+
+// GeneratorParams - параметры генерации пресета слотов материалов. Диапазон высот делится
+// на Bands равных полос; в каждой полосе Layers слоев: первый покрывает пологие участки,
+// каждый следующий - склоны круче своего порога из SlopeThresholds.
+type GeneratorParams struct {
+	Name            string
+	Description     string
+	Author          string
+	Layers          int
+	Bands           int
+	MinHeight       float64
+	MaxHeight       float64
+	SlopeThresholds []int // SlopeThresholdIndex слоев со второго по последний, Layers-1 значений
+	SlopeAction     int   // SlopeThresholdAction слоев на склонах
+	Textures        []GeneratorTexture
+	TextureCount    int // Размер каталога текстур; 0 - индексы не проверяются
+}
+
+// Slots возвращает число слотов, которое займет пресет
+func (p GeneratorParams) Slots() int {
+	return p.Layers * p.Bands
+}
+
+// validate проверяет согласованность параметров генерации
+func (p GeneratorParams) validate() error {
+	var problems []string
+	if err := ValidatePresetName(p.Name); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if p.Layers < 1 {
+		problems = append(problems, T("generator.badLayers", "Layers", p.Layers))
+	}
+	if p.Bands < 1 {
+		problems = append(problems, T("generator.badBands", "Bands", p.Bands))
+	}
+	if p.MaxHeight <= p.MinHeight {
+		problems = append(problems, T("generator.badHeights", "Min", p.MinHeight, "Max", p.MaxHeight))
+	}
+	if p.Layers >= 1 && len(p.SlopeThresholds) != p.Layers-1 {
+		problems = append(problems, T("generator.badSlopes", "Count", len(p.SlopeThresholds), "Expected", p.Layers-1))
+	}
+	if len(p.Textures) == 0 {
+		problems = append(problems, T("generator.noTextures"))
+	}
+	for _, texture := range p.Textures {
+		for _, index := range []int{texture.Horizontal, texture.Vertical} {
+			if index < 0 || p.TextureCount > 0 && index >= p.TextureCount {
+				problems = append(problems, T("lint.textureIndex", "Index", index, "Count", p.TextureCount))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidValue, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// GeneratePreset строит пресет собственного формата по параметрам. Полосы высот идут снизу вверх,
+// слоты нумеруются по полосам, текстуры берутся из списка по кругу.
+// Все ключи слота заполняются и проверяются по таблице вида.
+func GeneratePreset(params GeneratorParams) (*PresetFile, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	file := &PresetFile{
+		FormatVersion: presetFormatVersion,
+		Metadata: PresetMetadata{
+			Name:        params.Name,
+			Description: params.Description,
+			Author:      params.Author,
+			Source:      "generator",
+		},
+	}
+	step := (params.MaxHeight - params.MinHeight) / float64(params.Bands)
+	for band := 0; band < params.Bands; band++ {
+		low := params.MinHeight + step*float64(band)
+		high := params.MinHeight + step*float64(band+1)
+		if band == params.Bands-1 {
+			high = params.MaxHeight
+		}
+		for layer := 0; layer < params.Layers; layer++ {
+			slot := band*params.Layers + layer + 1
+			texture := params.Textures[(slot-1)%len(params.Textures)]
+
+			values := make(map[string]string)
+			for _, spec := range materialPairKind.Keys {
+				values[spec.Name] = spec.Default
+			}
+			values["PresetEnabled"] = "true"
+			values["Probability"] = "100"
+			values["HeightLowLimit"] = formatHeight(low)
+			values["HeightHighLimit"] = formatHeight(high)
+			// Нижняя полоса начинается с самого низа, верхняя не ограничена сверху
+			values["LowLimitMask"] = strconv.FormatBool(band > 0)
+			values["HighLimitMask"] = strconv.FormatBool(band < params.Bands-1)
+			values["SelectedHorizontalTexture"] = strconv.Itoa(texture.Horizontal)
+			values["SelectedVerticalTexture"] = strconv.Itoa(texture.Vertical)
+			if layer > 0 {
+				values["SlopeThresholdMask"] = "true"
+				values["SlopeThresholdIndex"] = strconv.Itoa(params.SlopeThresholds[layer-1])
+				values["SlopeThresholdAction"] = strconv.Itoa(params.SlopeAction)
+			}
+
+			file.Slots = append(file.Slots, PresetSection{
+				Path:   materialPairKind.suffix(strconv.Itoa(slot)),
+				Values: PresetValues{Keys: materialPairKind.keyOrder(), Values: values},
+			})
+		}
+	}
+
+	if err := validatePreset(params.Name, file.blocks(), BiomeFamily.Kinds); err != nil {
+		return nil, err
+	}
+	Logger.Info("preset generated", "preset", params.Name, "slots", len(file.Slots), "bands", params.Bands, "layers", params.Layers)
+	return file, nil
+}
+
+// ValidatePresetName проверяет, что имя пресета можно использовать как имя файла
+func ValidatePresetName(name string) error {
+	if strings.TrimSpace(name) == "" || strings.ContainsAny(name, `/\:*?"<>|`) {
+		return fmt.Errorf("%s", T("generator.badName", "Name", name))
+	}
+	return nil
+}
+
+// Summary возвращает по строке на слот: полоса высот, текстуры и порог крутизны
+func (f *PresetFile) Summary() []string {
+	lines := make([]string, 0, len(f.Slots))
+	for _, slot := range f.Slots {
+		values := slot.Values.Values
+		line := T("generator.slot",
+			"Slot", path.Base(slot.Path),
+			"Low", values["HeightLowLimit"],
+			"High", values["HeightHighLimit"],
+			"Horizontal", values["SelectedHorizontalTexture"],
+			"Vertical", values["SelectedVerticalTexture"])
+		if values["SlopeThresholdMask"] == "true" {
+			line += T("generator.slotSlope", "Index", values["SlopeThresholdIndex"], "Action", values["SlopeThresholdAction"])
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// formatHeight записывает высоту с точностью до сотых
+func formatHeight(height float64) string {
+	return strconv.FormatFloat(math.Round(height*100)/100, 'f', -1, 64)
+}
+
+// SavePreset записывает пресет собственного формата в JSON. Пустой путь - папка пресетов биомов
+// под именем из метаданных. Существующий файл перезаписывается только с overwrite.
+func SavePreset(file *PresetFile, path string, overwrite bool) (string, error) {
+	if path == "" {
+		if err := ValidatePresetName(file.Metadata.Name); err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(BiomeFamily.Folder, 0755); err != nil {
+			return "", fmt.Errorf("%s: %v", T("error.writeFile"), err)
+		}
+		path = BiomeFamily.NativePath(file.Metadata.Name)
+	}
+	if !overwrite && FileExists(path) {
+		return "", fmt.Errorf("%s", T("error.presetExists", "Path", path))
+	}
+	if err := savePresetFile(path, file); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ParseIntList разбирает список целых через запятую, например "4,7"
+func ParseIntList(text string) ([]int, error) {
+	var list []int
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		value, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s", T("generator.badNumber", "Value", item))
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+// ParseGeneratorTextures разбирает список текстур через запятую: "3" - одна текстура для пологих
+// участков и склонов, "3:7" - разные
+func ParseGeneratorTextures(text string) ([]GeneratorTexture, error) {
+	var textures []GeneratorTexture
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		horizontal, vertical, paired := strings.Cut(item, ":")
+		if !paired {
+			vertical = horizontal
+		}
+		pair, err := ParseIntList(horizontal + "," + vertical)
+		if err != nil || len(pair) != 2 {
+			return nil, fmt.Errorf("%s", T("generator.badTexture", "Value", item))
+		}
+		textures = append(textures, GeneratorTexture{Horizontal: pair[0], Vertical: pair[1]})
+	}
+	return textures, nil
+}
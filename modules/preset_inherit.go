@@ -0,0 +1,233 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// presetLayer - один файл цепочки наследования: его собственные блоки без учета родителей
+type presetLayer struct {
+	Name      string // Имя пресета - имя файла без расширения
+	Path      string
+	Blocks    map[string]PresetBlock
+	Variables map[string]PresetVariable
+	Extends   string // Имя родителя; пусто - пресет ничего не наследует
+}
+
+// loadPresetChain читает пресет и всех его родителей: первым идет сам пресет, последним - корень цепочки.
+// Родитель ищется по имени в папке пресета, затем в локальных папках семейств, куда пишет загрузка
+// из источников профиля. Если родителя нет или цепочка замыкается, возвращается *InheritanceError.
+func loadPresetChain(path string) ([]presetLayer, error) {
+	var chain []presetLayer
+	visited := make(map[string]bool)
+	for {
+		absolute, err := filepath.Abs(path)
+		if err != nil {
+			absolute = path
+		}
+		if visited[absolute] {
+			return nil, &InheritanceError{Path: chain[0].Path, Chain: append(chainNames(chain), chain[len(chain)-1].Extends)}
+		}
+		visited[absolute] = true
+
+		layer, err := readPresetLayer(path)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, layer)
+		if layer.Extends == "" {
+			return chain, nil
+		}
+
+		path = findParentPreset(path, layer.Extends)
+		if path == "" {
+			return nil, &InheritanceError{Path: chain[0].Path, Chain: chainNames(chain), Missing: layer.Extends}
+		}
+		Logger.Debug("preset parent found", "preset", layer.Name, "parent", path)
+	}
+}
+
+// chainNames возвращает имена пресетов цепочки по порядку
+func chainNames(chain []presetLayer) []string {
+	names := make([]string, len(chain))
+	for i, layer := range chain {
+		names[i] = layer.Name
+	}
+	return names
+}
+
+// findParentPreset возвращает путь к родительскому пресету или пустую строку, если его нет локально
+func findParentPreset(childPath, parentName string) string {
+	if ValidatePresetName(parentName) != nil {
+		return ""
+	}
+	folders := []string{filepath.Dir(childPath)}
+	for _, family := range PresetFamilies {
+		folders = append(folders, family.Folder)
+	}
+	for _, folder := range folders {
+		for _, ext := range presetExtensions {
+			if path := filepath.Join(folder, parentName+ext); FileExists(path) {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// mergePresetChain накладывает блоки цепочки от корня к пресету. Секции сопоставляются по виду
+// и номеру слота или имени инструмента, как при сравнении, а секции неизвестного вида - по заголовку:
+// потомок заменяет только те ключи, которые в нем заданы, остальные берутся у родителя.
+// Секции, которых у родителя нет, добавляются.
+func mergePresetChain(chain []presetLayer) map[string]PresetBlock {
+	if len(chain) == 1 {
+		return chain[0].Blocks
+	}
+
+	merged := make(map[string]PresetBlock)
+	headers := make(map[sectionRef]string)
+	for i := len(chain) - 1; i >= 0; i-- {
+		for header, block := range chain[i].Blocks {
+			ref, known := kindOf(header)
+			parentHeader, inherited := headers[ref]
+			if !known {
+				parentHeader = header
+				_, inherited = merged[header]
+			}
+			if !inherited {
+				merged[header] = PresetBlock{Header: header, Keys: slices.Clone(block.keys()), Values: cloneValues(block.Values)}
+				if known {
+					headers[ref] = header
+				}
+				continue
+			}
+
+			parent := merged[parentHeader]
+			delete(merged, parentHeader)
+			for _, key := range block.keys() {
+				if _, exists := parent.Values[key]; !exists {
+					parent.Keys = append(parent.Keys, key)
+				}
+				parent.Values[key] = block.Values[key]
+			}
+			parent.Header = header
+			merged[header] = parent
+			if known {
+				headers[ref] = header
+			}
+		}
+	}
+	return merged
+}
+
+// cloneValues копирует значения блока, чтобы наложение потомка не меняло блоки родителя
+func cloneValues(values map[string]string) map[string]string {
+	clone := make(map[string]string, len(values))
+	for key, value := range values {
+		clone[key] = value
+	}
+	return clone
+}
+
+// InheritedValue - значение ключа пресета с учетом наследования и пресет, из которого оно взято
+type InheritedValue struct {
+	Key         string
+	Value       string
+	Origin      string // Пресет цепочки, в котором задано значение
+	Overridden  bool   // Ключ есть и у родителя, но пресет Origin задает свое значение
+	ParentValue string // Значение родителя, которое заменено (при Overridden)
+}
+
+// InheritedSection - секция пресета со значениями по происхождению
+type InheritedSection struct {
+	Path   string
+	Values []InheritedValue
+}
+
+// PresetInheritance - пресет с цепочкой родителей и происхождением каждого значения
+type PresetInheritance struct {
+	Chain    []string // Сам пресет, затем родители до корня
+	Sections []InheritedSection
+}
+
+// InheritanceOf возвращает цепочку наследования пресета и происхождение его значений.
+// Аргумент - имя локального пресета (все семейства с этим именем) или путь к файлу, как в ComparePresets.
+func InheritanceOf(nameOrPath string) (*PresetInheritance, error) {
+	var paths []string
+	if isPresetFile(nameOrPath) && FileExists(nameOrPath) {
+		paths = []string{nameOrPath}
+	} else {
+		for _, family := range presetFamiliesOf(nameOrPath) {
+			paths = append(paths, family.Path(nameOrPath))
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("%s", T("error.presetNotFound", "Name", nameOrPath))
+		}
+	}
+
+	inheritance := &PresetInheritance{}
+	for _, path := range paths {
+		chain, err := loadPresetChain(path)
+		if err != nil {
+			return nil, err
+		}
+		inheritance.Chain = appendUnique(inheritance.Chain, chainNames(chain)...)
+		inheritance.Sections = append(inheritance.Sections, inheritedSections(chain)...)
+	}
+	return inheritance, nil
+}
+
+// inheritedSections проходит цепочку от корня и запоминает для каждого ключа, где он задан последним
+func inheritedSections(chain []presetLayer) []InheritedSection {
+	sections := make(map[sectionRef]*InheritedSection)
+	var refs []sectionRef
+	for i := len(chain) - 1; i >= 0; i-- {
+		for header, block := range chain[i].Blocks {
+			ref, known := kindOf(header)
+			if !known {
+				continue
+			}
+			section, exists := sections[ref]
+			if !exists {
+				section = &InheritedSection{Path: ref.Kind.suffix(ref.ID)}
+				sections[ref] = section
+				refs = append(refs, ref)
+			}
+			for _, key := range block.keys() {
+				value := InheritedValue{Key: key, Value: block.Values[key], Origin: chain[i].Name}
+				index := slices.IndexFunc(section.Values, func(v InheritedValue) bool { return v.Key == key })
+				if index < 0 {
+					section.Values = append(section.Values, value)
+					continue
+				}
+				value.Overridden = true
+				value.ParentValue = section.Values[index].Value
+				section.Values[index] = value
+			}
+		}
+	}
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].less(refs[j]) })
+	result := make([]InheritedSection, len(refs))
+	for i, ref := range refs {
+		result[i] = *sections[ref]
+	}
+	return result
+}
+
+// FetchPresetParents загружает пресеты из источников профиля, если родителя кого-то из пресетов
+// с этим именем нет локально. Если родителя нет и в источниках, ошибку вернет чтение пресета.
+func FetchPresetParents(profile *Profile, presetName string) error {
+	for _, family := range presetFamiliesOf(presetName) {
+		_, err := loadPresetChain(family.Path(presetName))
+		var inheritance *InheritanceError
+		if errors.As(err, &inheritance) && inheritance.Missing != "" {
+			Logger.Info("fetching presets for missing parent", "preset", presetName, "parent", inheritance.Missing)
+			return FetchPresets(profile)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package modules
+
+import (
+	"errors"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeInheritedPreset сохраняет пресет собственного формата с родителем extends и возвращает путь
+func writeInheritedPreset(t *testing.T, dir, name, extends string, sections map[string]map[string]string) string {
+	t.Helper()
+	blocks := make(map[string]PresetBlock, len(sections))
+	for path, values := range sections {
+		header := sectionHeader(path)
+		blocks[header] = PresetBlock{Header: header, Values: values}
+	}
+	file := newPresetFile(name, blocks)
+	file.Extends = extends
+	path := filepath.Join(dir, name+".json")
+	if err := savePresetFile(path, file); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPresetChainErrors(t *testing.T) {
+	slot := map[string]map[string]string{"Tools/TerrainEdit/MaterialPairSlot1": {"Probability": "50"}}
+	tests := []struct {
+		name    string
+		presets map[string]string // Имя пресета → родитель
+		start   string            // Пресет, с которого читается цепочка
+		chain   []string
+		missing string
+	}{
+		{"пресет наследует сам себя", map[string]string{"loop_self": "loop_self"}, "loop_self", []string{"loop_self", "loop_self"}, ""},
+		{"цикл из двух пресетов", map[string]string{"loop_a": "loop_b", "loop_b": "loop_a"}, "loop_a", []string{"loop_a", "loop_b", "loop_a"}, ""},
+		{"цикл через корень цепочки", map[string]string{"loop_a": "loop_b", "loop_b": "loop_c", "loop_c": "loop_b"}, "loop_a", []string{"loop_a", "loop_b", "loop_c", "loop_b"}, ""},
+		{"нет родителя", map[string]string{"loop_a": "no_such_parent"}, "loop_a", []string{"loop_a"}, "no_such_parent"},
+		{"нет родителя у родителя", map[string]string{"loop_a": "loop_b", "loop_b": "no_such_parent"}, "loop_a", []string{"loop_a", "loop_b"}, "no_such_parent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, extends := range tt.presets {
+				writeInheritedPreset(t, dir, name, extends, slot)
+			}
+			path := filepath.Join(dir, tt.start+".json")
+
+			_, err := loadPresetChain(path)
+			var inheritance *InheritanceError
+			if !errors.As(err, &inheritance) || !errors.Is(err, ErrInheritance) {
+				t.Fatalf("ошибка %v, ожидалась InheritanceError", err)
+			}
+			if !slices.Equal(inheritance.Chain, tt.chain) || inheritance.Missing != tt.missing || inheritance.Path != path {
+				t.Errorf("цепочка %v, нет родителя %q, путь %s", inheritance.Chain, inheritance.Missing, inheritance.Path)
+			}
+		})
+	}
+}
+
+func TestPresetInheritanceOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeInheritedPreset(t, dir, "base_forest", "", map[string]map[string]string{
+		"Tools/TerrainEdit/MaterialPairSlot1": {"Probability": "40", "HeightLowLimit": "10"},
+		"Tools/TerrainEdit/MaterialPairSlot2": {"Probability": "20"},
+	})
+	writeInheritedPreset(t, dir, "forest", "base_forest", map[string]map[string]string{
+		"Tools/TerrainEdit/MaterialPairSlot1": {"Probability": "55"},
+	})
+	path := writeInheritedPreset(t, dir, "dark_forest", "forest", map[string]map[string]string{
+		"Tools/TerrainEdit/MaterialPairSlot1": {"Probability": "60"},
+		"Tools/TerrainEdit/MaterialPairSlot3": {"Probability": "5"},
+	})
+
+	chain, err := loadPresetChain(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := chainNames(chain); !slices.Equal(names, []string{"dark_forest", "forest", "base_forest"}) {
+		t.Fatalf("цепочка %v", names)
+	}
+
+	merged := make(map[string]map[string]string)
+	for header, block := range mergePresetChain(chain) {
+		ref, _ := kindOf(header)
+		merged[ref.Kind.suffix(ref.ID)] = block.Values
+	}
+	want := map[string]map[string]string{
+		"Tools/TerrainEdit/MaterialPairSlot1": {"Probability": "60", "HeightLowLimit": "10"},
+		"Tools/TerrainEdit/MaterialPairSlot2": {"Probability": "20"},
+		"Tools/TerrainEdit/MaterialPairSlot3": {"Probability": "5"},
+	}
+	if len(merged) != len(want) {
+		t.Errorf("секции %v", merged)
+	}
+	for path, values := range want {
+		if !maps.Equal(merged[path], values) {
+			t.Errorf("%s = %v, ожидалось %v", path, merged[path], values)
+		}
+	}
+
+	sections := inheritedSections(chain)
+	if len(sections) != 3 || sections[0].Path != "Tools/TerrainEdit/MaterialPairSlot1" {
+		t.Fatalf("секции наследования %+v", sections)
+	}
+	values := make(map[string]InheritedValue)
+	for _, value := range sections[0].Values {
+		values[value.Key] = value
+	}
+	if got := values["Probability"]; got != (InheritedValue{Key: "Probability", Value: "60", Origin: "dark_forest", Overridden: true, ParentValue: "55"}) {
+		t.Errorf("Probability: %+v", got)
+	}
+	if got := values["HeightLowLimit"]; got != (InheritedValue{Key: "HeightLowLimit", Value: "10", Origin: "base_forest"}) {
+		t.Errorf("HeightLowLimit: %+v", got)
+	}
+	if got := sections[1].Values; len(got) != 1 || got[0].Origin != "base_forest" || got[0].Overridden {
+		t.Errorf("слот 2 не из base_forest: %+v", got)
+	}
+}
+
+func TestMergePresetChainUnknownSection(t *testing.T) {
+	header := "[/Tools/Custom/Settings]"
+	chain := []presetLayer{
+		{Name: "child", Blocks: map[string]PresetBlock{header: {Header: header, Keys: []string{"Size"}, Values: map[string]string{"Size": "5"}}}},
+		{Name: "parent", Blocks: map[string]PresetBlock{header: {Header: header, Keys: []string{"Size", "Mode"}, Values: map[string]string{"Size": "1", "Mode": "soft"}}}},
+	}
+	merged := mergePresetChain(chain)
+	block, ok := merged[header]
+	if !ok || len(merged) != 1 {
+		t.Fatalf("секции %v", merged)
+	}
+	if !maps.Equal(block.Values, map[string]string{"Size": "5", "Mode": "soft"}) || !slices.Equal(block.Keys, []string{"Size", "Mode"}) {
+		t.Errorf("секция неизвестного вида %+v, ожидалось наложение по ключам", block)
+	}
+	if chain[1].Blocks[header].Values["Size"] != "1" {
+		t.Error("наложение изменило блок родителя")
+	}
+}
@@ -0,0 +1,536 @@
+package modules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Уровни замечаний проверки пресетов
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintIssue - замечание проверки файла пресета. Поля с тегами json - машиночитаемый вывод
+// команды validate --json (например, для pre-commit в репозитории biomebrushes).
+type LintIssue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"` // 0 - замечание относится ко всему файлу
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Section  string `json:"section,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Message  string `json:"message"`
+}
+
+// String возвращает замечание в виде "файл:строка: уровень: секция ключ: сообщение"
+func (i LintIssue) String() string {
+	position := i.File
+	if i.Line > 0 {
+		position = fmt.Sprintf("%s:%d", i.File, i.Line)
+	}
+	subject := strings.TrimSpace(i.Section + " " + i.Key)
+	if subject == "" {
+		return fmt.Sprintf("%s: %s: %s", position, i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", position, i.Severity, subject, i.Message)
+}
+
+// LintOptions - настройки проверки
+type LintOptions struct {
+	TextureCount int // Размер каталога текстур; 0 - индексы текстур не проверяются
+}
+
+// HasLintErrors сообщает, есть ли среди замечаний ошибки (а не только предупреждения)
+func HasLintErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == LintError {
+			return true
+		}
+	}
+	return false
+}
+
+// lintEntry - ключ секции с номером строки
+type lintEntry struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+// lintSection - секция пресета с позициями, в порядке файла; повторы секций и ключей сохраняются
+type lintSection struct {
+	Header  string
+	Line    int
+	Entries []lintEntry
+	Strict  bool // Повтор ключа - ошибка: пресет собственного формата с ним не читается
+}
+
+// LintPresets проверяет файлы пресетов; для папки проверяются все файлы поддерживаемых форматов в ней.
+// Замечания сортируются по файлу и строке.
+func LintPresets(paths []string, options LintOptions) ([]LintIssue, error) {
+	var issues []LintIssue
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{path}
+		if info.IsDir() {
+			if files, err = presetFilesIn(path); err != nil {
+				return nil, err
+			}
+		}
+		for _, file := range files {
+			issues = append(issues, LintPresetFile(file, options)...)
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues, nil
+}
+
+// presetFilesIn возвращает файлы пресетов в папке по алфавиту
+func presetFilesIn(folder string) ([]string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.presetsFolderRead"), err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isPresetFile(entry.Name()) {
+			files = append(files, filepath.Join(folder, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// isPresetFile сообщает, поддерживается ли формат файла по расширению
+func isPresetFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, supported := range presetExtensions {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// LintPresetFile проверяет один файл пресета. Ошибка чтения или разбора тоже возвращается замечанием.
+func LintPresetFile(path string, options LintOptions) []LintIssue {
+	sections, issues, err := readLintSections(path)
+	if err != nil {
+		return []LintIssue{{File: path, Severity: LintError, Code: "parse", Message: err.Error()}}
+	}
+	// Нарушения схемы и повторы ключей собственного формата не дают прочитать сам файл при применении
+	unreadable := len(issues) > 0
+	for _, section := range sections {
+		if section.Strict && hasDuplicateKey(section.Entries) {
+			unreadable = true
+		}
+	}
+
+	add := func(line int, severity, code, section, key, message string) {
+		issues = append(issues, LintIssue{File: path, Line: line, Severity: severity, Code: code, Section: section, Key: key, Message: message})
+	}
+
+	// Значения переменных шаблонов при проверке - только значения по умолчанию из объявлений
+	defaults := make(map[string]string)
+	for name, variable := range readPresetVariables(path) {
+		if variable.Default != "" {
+			defaults[name] = variable.Default
+		}
+	}
+
+	// Ключи, которых нет в самом пресете, может задать родитель (extends). Если сам файл читается,
+	// ошибка цепочки относится к родителям; иначе ошибки файла уже есть в замечаниях по строкам.
+	// Без цепочки неизвестно, какие ключи задает родитель, поэтому недостающие ключи не ищутся.
+	var inherited map[sectionRef]PresetBlock
+	chain, err := loadPresetChain(path)
+	if err != nil && !unreadable {
+		add(0, LintError, "extends", "", "", err.Error())
+	} else if len(chain) > 1 {
+		inherited, _ = presetSections(mergePresetChain(chain[1:]))
+	}
+
+	seen := make(map[sectionRef]int)
+	for _, section := range sections {
+		ref, ok := kindOf(section.Header)
+		// Испорченный номер слота (MaterialPairSlotX) иначе был бы принят за инструмент TerrainEdit
+		if ok && ref.Kind != materialPairKind && strings.Contains(section.Header, materialPairKind.Name) {
+			ok = false
+		}
+		if !ok {
+			add(section.Line, LintError, "unknown-section", section.Header, "", T("lint.unknownSection"))
+			continue
+		}
+		if first, duplicate := seen[ref]; duplicate {
+			add(section.Line, LintError, "duplicate-section", section.Header, "", T("lint.duplicateSection", "Line", first))
+		} else {
+			seen[ref] = section.Line
+		}
+
+		values := make(map[string]string)
+		if parent, ok := inherited[ref]; ok {
+			values = cloneValues(parent.Values)
+		}
+		keyLines := make(map[string]int)
+		for _, entry := range section.Entries {
+			if first, duplicate := keyLines[entry.Key]; duplicate {
+				severity := LintWarning
+				if section.Strict {
+					severity = LintError
+				}
+				add(entry.Line, severity, "duplicate-key", section.Header, entry.Key, T("lint.duplicateKey", "Line", first))
+			} else {
+				keyLines[entry.Key] = entry.Line
+			}
+			value := entry.Value
+			values[entry.Key] = value
+			if isTemplate(value) {
+				resolved, complete, err := checkTemplate(value, defaults)
+				if err != nil {
+					add(entry.Line, LintError, "template", section.Header, entry.Key, fmt.Sprintf("%s=%s: %v", entry.Key, entry.Value, err))
+					continue
+				}
+				// Без значений всех переменных тип проверить нельзя: значение станет известно при применении
+				if !complete {
+					continue
+				}
+				value = resolved
+				values[entry.Key] = value
+			}
+			if err := ref.Kind.validateValue(entry.Key, value); err != nil {
+				add(entry.Line, LintError, "invalid-value", section.Header, entry.Key, fmt.Sprintf("%s=%s: %v", entry.Key, entry.Value, err))
+			}
+		}
+
+		var missing []string
+		for _, spec := range ref.Kind.Keys {
+			if _, exists := values[spec.Name]; !exists && spec.Default != "" {
+				missing = append(missing, spec.Name)
+			}
+		}
+		if len(missing) > 0 && chain != nil {
+			add(section.Line, LintWarning, "missing-key", section.Header, "", T("lint.missingKeys", "Keys", strings.Join(missing, ", ")))
+		}
+
+		if ref.Kind == materialPairKind {
+			issues = append(issues, lintMaterialSlot(path, section.Header, values, keyLines, options)...)
+		}
+	}
+	return issues
+}
+
+// hasDuplicateKey сообщает, повторяется ли ключ среди ключей секции
+func hasDuplicateKey(entries []lintEntry) bool {
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if seen[entry.Key] {
+			return true
+		}
+		seen[entry.Key] = true
+	}
+	return false
+}
+
+// lintMaterialSlot проверяет согласованность значений слота материалов
+func lintMaterialSlot(path, header string, values map[string]string, keyLines map[string]int, options LintOptions) []LintIssue {
+	var issues []LintIssue
+	issue := func(key, severity, code, message string) {
+		issues = append(issues, LintIssue{File: path, Line: keyLines[key], Severity: severity, Code: code, Section: header, Key: key, Message: message})
+	}
+
+	low, lowErr := strconv.ParseFloat(values["HeightLowLimit"], 64)
+	high, highErr := strconv.ParseFloat(values["HeightHighLimit"], 64)
+	if lowErr == nil && highErr == nil && low > high {
+		issue("HeightLowLimit", LintError, "height-limits", T("lint.heightLimits", "Low", values["HeightLowLimit"], "High", values["HeightHighLimit"]))
+	}
+
+	if options.TextureCount > 0 {
+		for _, key := range []string{"SelectedHorizontalTexture", "SelectedVerticalTexture"} {
+			index, err := strconv.Atoi(values[key])
+			if err == nil && index >= options.TextureCount {
+				issue(key, LintError, "texture-index", T("lint.textureIndex", "Index", index, "Count", options.TextureCount))
+			}
+		}
+	}
+
+	// Выключенный слот с непустыми значениями - обычно забытая правка
+	if enabled, err := strconv.ParseBool(values["PresetEnabled"]); err == nil && !enabled {
+		var changed []string
+		for _, spec := range materialPairKind.Keys {
+			value, exists := values[spec.Name]
+			if exists && spec.Name != "PresetEnabled" && !sameValue(value, spec.Default) {
+				changed = append(changed, spec.Name)
+			}
+		}
+		if len(changed) > 0 {
+			issue("PresetEnabled", LintWarning, "disabled-with-values", T("lint.disabledWithValues", "Keys", strings.Join(changed, ", ")))
+		}
+	}
+	return issues
+}
+
+// sameValue сравнивает значения ключей; числа сравниваются по значению (1 и 1.0 равны)
+func sameValue(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return errA == nil && errB == nil && x == y
+}
+
+// readLintSections читает секции пресета с номерами строк из файла любого поддерживаемого формата.
+// Для собственного формата возвращает и замечания по строкам: нарушения схемы и повторы ключей.
+// Ошибка возвращается, только если файл нельзя прочитать как JSON/YAML.
+func readLintSections(path string) ([]lintSection, []LintIssue, error) {
+	if strings.ToLower(filepath.Ext(path)) == ".txt" {
+		sections, err := readTxtLintSections(path)
+		return sections, nil, err
+	}
+	if !isPresetFile(path) {
+		return nil, nil, fmt.Errorf("%s", T("error.presetFormat", "Path", path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", T("error.presetOpen"), err)
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", T("error.presetParse", "Path", path), err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("%s", T("error.presetParse", "Path", path))
+	}
+	root := document.Content[0]
+
+	if !isNativePreset(&document) {
+		// Пронумерованный JSON: {"1": {"path": ..., ключи}, ...}
+		var sections []lintSection
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			block := root.Content[i+1]
+			section := lintSection{Line: root.Content[i].Line}
+			for j := 0; j+1 < len(block.Content); j += 2 {
+				key, value := block.Content[j], block.Content[j+1]
+				if key.Value == "path" {
+					section.Header = numberedHeader(value.Value)
+					continue
+				}
+				section.Entries = append(section.Entries, lintEntry{Key: key.Value, Value: value.Value, Line: key.Line})
+			}
+			sections = append(sections, section)
+		}
+		return sections, nil, nil
+	}
+
+	// Собственный формат проверяется по схеме без разбора в структуры: повтор ключа или ошибка
+	// в одном слоте не должны скрывать замечания к остальным
+	issues, err := nativeLintIssues(path, root)
+	if err != nil {
+		return nil, nil, err
+	}
+	var sections []lintSection
+	if slots := mappingValue(root, "slots"); slots != nil && slots.Kind == yaml.SequenceNode {
+		for _, slot := range slots.Content {
+			section := lintSection{Line: slot.Line, Strict: true}
+			if path := mappingValue(slot, "path"); path != nil {
+				section.Header = sectionHeader(path.Value)
+				section.Line = path.Line
+			}
+			if values := mappingValue(slot, "values"); values != nil {
+				for j := 0; j+1 < len(values.Content); j += 2 {
+					key, value := values.Content[j], values.Content[j+1]
+					// Объект или список вместо значения уже отмечен проверкой схемы
+					if value.Kind != yaml.ScalarNode {
+						continue
+					}
+					section.Entries = append(section.Entries, lintEntry{Key: key.Value, Value: value.Value, Line: key.Line})
+				}
+			}
+			sections = append(sections, section)
+		}
+	}
+	return sections, issues, nil
+}
+
+// nativeLintIssues проверяет документ собственного формата по схеме preset.schema.json и ищет
+// повторы ключей вне значений слотов (их проверяет LintPresetFile). Строка замечания схемы -
+// строка поля, на которое указывает путь ошибки.
+func nativeLintIssues(path string, root *yaml.Node) ([]LintIssue, error) {
+	issues := duplicateKeyIssues(path, root)
+
+	raw, err := yamlNodeValue(root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.presetParse", "Path", path), err)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.presetParse", "Path", path), err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.presetParse", "Path", path), err)
+	}
+	err = validateAgainstSchema("preset.schema.json", generic)
+	var schemaErrs SchemaErrors
+	if err != nil && !errors.As(err, &schemaErrs) {
+		return nil, err
+	}
+	for _, schemaErr := range schemaErrs {
+		issues = append(issues, LintIssue{File: path, Line: schemaFieldLine(root, schemaErr.Field), Severity: LintError, Code: "schema", Key: schemaErr.Field, Message: schemaErr.Message})
+	}
+	return issues, nil
+}
+
+// duplicateKeyIssues ищет повторы ключей в объектах документа, кроме values слотов
+func duplicateKeyIssues(path string, node *yaml.Node) []LintIssue {
+	var issues []LintIssue
+	switch node.Kind {
+	case yaml.MappingNode:
+		lines := make(map[string]int)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if first, duplicate := lines[key.Value]; duplicate {
+				issues = append(issues, LintIssue{File: path, Line: key.Line, Severity: LintError, Code: "duplicate-key", Key: key.Value, Message: T("lint.duplicateKey", "Line", first)})
+			} else {
+				lines[key.Value] = key.Line
+			}
+			if key.Value != "values" {
+				issues = append(issues, duplicateKeyIssues(path, node.Content[i+1])...)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			issues = append(issues, duplicateKeyIssues(path, item)...)
+		}
+	}
+	return issues
+}
+
+// yamlNodeValue переводит узел YAML в значения map/slice/скаляры; из повторов ключа берется последний
+func yamlNodeValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlNodeValue(node.Alias)
+	case yaml.MappingNode:
+		values := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlNodeValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			values[node.Content[i].Value] = value
+		}
+		return values, nil
+	case yaml.SequenceNode:
+		items := make([]interface{}, len(node.Content))
+		for i, item := range node.Content {
+			value, err := yamlNodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = value
+		}
+		return items, nil
+	default:
+		var value interface{}
+		err := node.Decode(&value)
+		return value, err
+	}
+}
+
+// schemaFieldLine возвращает строку поля по пути ошибки схемы вида slots[0].values.Probability.
+// Если поля в документе нет (например, обязательного), берется строка ближайшего объекта на пути.
+func schemaFieldLine(root *yaml.Node, field string) int {
+	node, line := root, root.Line
+	if field == "" {
+		return line
+	}
+	for _, part := range strings.Split(field, ".") {
+		name, indexes, _ := strings.Cut(part, "[")
+		if name != "" {
+			index := mappingIndex(node, name)
+			if index < 0 {
+				return line
+			}
+			line = node.Content[index].Line
+			node = node.Content[index+1]
+		}
+		for indexes != "" {
+			text, rest, _ := strings.Cut(indexes, "]")
+			indexes = strings.TrimPrefix(rest, "[")
+			i, err := strconv.Atoi(text)
+			if err != nil || node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+				return line
+			}
+			node = node.Content[i]
+			line = node.Line
+		}
+	}
+	return line
+}
+
+// mappingValue возвращает значение ключа объекта YAML/JSON или nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if index := mappingIndex(node, key); index >= 0 {
+		return node.Content[index+1]
+	}
+	return nil
+}
+
+// mappingIndex возвращает индекс узла первого такого ключа в Content объекта или -1
+func mappingIndex(node *yaml.Node, key string) int {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// readTxtLintSections читает секции старого формата TXT с номерами строк
+func readTxtLintSections(path string) ([]lintSection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", T("error.presetOpen"), err)
+	}
+	text, err := decodeWindows1251(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var sections []lintSection
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "[") {
+			sections = append(sections, lintSection{Header: strings.TrimSpace(line), Line: i + 1})
+			continue
+		}
+		if len(sections) == 0 {
+			continue
+		}
+		if key, value, ok := splitKeyValue(line); ok {
+			current := &sections[len(sections)-1]
+			current.Entries = append(current.Entries, lintEntry{Key: key, Value: value, Line: i + 1})
+		}
+	}
+	return sections, nil
+}
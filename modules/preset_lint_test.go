@@ -0,0 +1,127 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// lintPositions возвращает замечания в виде "код:строка" без предупреждений о недостающих ключах
+func lintPositions(issues []LintIssue) []string {
+	var positions []string
+	for _, issue := range issues {
+		if issue.Code != "missing-key" {
+			positions = append(positions, fmt.Sprintf("%s:%d", issue.Code, issue.Line))
+		}
+	}
+	slices.Sort(positions)
+	return positions
+}
+
+func TestLintPresetFile(t *testing.T) {
+	slot := `[Session/dlc\proj\data\levels\world\world.w2w/Tools/TerrainEdit/MaterialPairSlot1]`
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string // "код:строка" по возрастанию
+	}{
+		{"txt: повтор ключа", "dup.txt", slot + "\nProbability=80\nProbability=90\n", []string{"duplicate-key:3"}},
+		{"txt: низ выше верха", "heights.txt", slot + "\nHeightLowLimit=200\nHeightHighLimit=100\n", []string{"height-limits:2"}},
+		{"txt: индекс текстуры", "texture.txt", slot + "\nSelectedHorizontalTexture=3\nSelectedVerticalTexture=40\n", []string{"texture-index:3"}},
+		{"пронумерованный JSON: повтор ключа", "dup.json", `{
+  "1": {
+    "path": "[[Session/dlc\\proj\\data\\levels\\world\\world.w2w/Tools/TerrainEdit/MaterialPairSlot1]]",
+    "Probability": 80,
+    "Probability": 90
+  }
+}`, []string{"duplicate-key:5"}},
+		{"пронумерованный JSON: низ выше верха и текстура", "slot.json", `{
+  "1": {
+    "path": "[[Session/dlc\\proj\\data\\levels\\world\\world.w2w/Tools/TerrainEdit/MaterialPairSlot1]]",
+    "HeightLowLimit": 200,
+    "HeightHighLimit": 100,
+    "SelectedHorizontalTexture": 35
+  }
+}`, []string{"height-limits:4", "texture-index:6"}},
+		{"собственный JSON: повтор ключа не скрывает остальные замечания", "native.json", `{
+  "formatVersion": 1,
+  "slots": [
+    {
+      "path": "Tools/TerrainEdit/MaterialPairSlot1",
+      "values": {
+        "Probability": 80,
+        "Probability": 90,
+        "HeightLowLimit": 200,
+        "HeightHighLimit": 100,
+        "SelectedVerticalTexture": 32
+      }
+    }
+  ]
+}`, []string{"duplicate-key:8", "height-limits:9", "texture-index:11"}},
+		{"YAML: повтор ключа, низ выше верха и текстура", "native.yaml", `formatVersion: 1
+slots:
+  - path: Tools/TerrainEdit/MaterialPairSlot1
+    values:
+      HeightLowLimit: 50
+      HeightHighLimit: 10
+      HeightHighLimit: 20
+      SelectedHorizontalTexture: 64
+`, []string{"duplicate-key:7", "height-limits:5", "texture-index:8"}},
+		{"YAML: нарушения схемы по строкам", "schema.yaml", `formatVersion: 1
+metadata:
+  jitter:
+    Probability: -5
+slots:
+  - path: Tools/TerrainEdit/MaterialPairSlot1
+    values:
+      Probability: {value: 3}
+      HeightLowLimit: 9
+      HeightHighLimit: 1
+    extra: 1
+`, []string{"height-limits:9", "schema:11", "schema:4", "schema:8"}},
+		{"YAML: повтор ключа вне значений слота", "meta.yaml", `formatVersion: 1
+metadata:
+  name: swamp
+  name: marsh
+slots: []
+`, []string{"duplicate-key:4"}},
+		{"YAML: сам файл не читается", "broken.yaml", "formatVersion: 1\nslots: [\n", []string{"parse:0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			issues := LintPresetFile(path, LintOptions{TextureCount: 32})
+			if got := lintPositions(issues); !slices.Equal(got, tt.want) {
+				t.Errorf("замечания %v, ожидалось %v:\n%v", got, tt.want, issues)
+			}
+		})
+	}
+}
+
+func TestLintDuplicateKeySeverity(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// Повтор в TXT и пронумерованном JSON только предупреждение: берется последнее значение
+		"numbered.json": `{"1": {"path": "[[Session/dlc\\proj\\data\\levels\\world\\world.w2w/Tools/TerrainEdit/MaterialPairSlot1]]", "Probability": 80, "Probability": 90}}`,
+		// Собственный формат с повтором ключа не читается при применении
+		"native.yaml": "formatVersion: 1\nslots:\n  - path: Tools/TerrainEdit/MaterialPairSlot1\n    values:\n      Probability: 80\n      Probability: 90\n",
+	}
+	want := map[string]string{"numbered.json": LintWarning, "native.yaml": LintError}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, issue := range LintPresetFile(path, LintOptions{}) {
+			if issue.Code == "duplicate-key" && issue.Severity != want[name] {
+				t.Errorf("%s: уровень %s, ожидался %s", name, issue.Severity, want[name])
+			}
+		}
+	}
+}
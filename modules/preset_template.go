@@ -0,0 +1,483 @@
+package modules
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PresetVariable - переменная шаблона пресета. Значение берется из флага --var или ответа
+// на запрос, затем из variables профиля, затем из Default; без значения пресет не применяется.
+type PresetVariable struct {
+	Name        string `json:"-" yaml:"-"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Default     string `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// Ссылка на переменную в значении ключа: ${имя}
+var templateVariablePattern = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// Имя переменной во флаге --var: буква или "_", затем буквы, цифры и "_"
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Наибольшая вложенность скобок, унарных минусов и вызовов функций в выражении.
+// Без предела выражение вроде "((((...))))" из чужого пресета исчерпывает стек.
+const maxExpressionDepth = 64
+
+// isTemplate сообщает, ссылается ли значение ключа на переменные
+func isTemplate(value string) bool {
+	return strings.Contains(value, "${")
+}
+
+// templateNames возвращает имена переменных, на которые ссылается значение
+func templateNames(value string) []string {
+	var names []string
+	for _, match := range templateVariablePattern.FindAllStringSubmatch(value, -1) {
+		names = appendUnique(names, match[1])
+	}
+	return names
+}
+
+// evalTemplate вычисляет значение ключа с переменными. Значение из одной ссылки "${имя}"
+// заменяется значением переменной как есть (число, true/false). Иначе значение - выражение
+// над числами: + - * /, скобки и функции min, max, round, floor, ceil, например "${baseHeight}+0.1".
+func evalTemplate(value string, variables map[string]string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	if match := templateVariablePattern.FindStringSubmatch(trimmed); match != nil && match[0] == trimmed {
+		variable, ok := variables[match[1]]
+		if !ok {
+			return "", fmt.Errorf("%s", T("template.unknownVariable", "Name", match[1]))
+		}
+		return variable, nil
+	}
+
+	parser := &expressionParser{text: []rune(trimmed), variables: variables}
+	result, err := parser.parse()
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", T("template.badExpression", "Expression", value), err)
+	}
+	// Шести знаков хватает для значений слотов и не дает хвостов вроде 0.30000000000000004
+	return strconv.FormatFloat(math.Round(result*1e6)/1e6, 'f', -1, 64), nil
+}
+
+// expressionParser - разбор выражения рекурсивным спуском. Выражение только вычисляется:
+// вызвать что-то кроме арифметики и перечисленных функций из него нельзя.
+//
+//	expression = term { ("+" | "-") term }
+//	term       = factor { ("*" | "/") factor }
+//	factor     = number | "${" name "}" | "(" expression ")" | "-" factor | function "(" expression { "," expression } ")"
+type expressionParser struct {
+	text      []rune
+	position  int
+	depth     int // Текущая вложенность factor
+	variables map[string]string
+}
+
+// Функции выражений шаблонов
+var templateFunctions = map[string]func(args []float64) (float64, bool){
+	"min":   func(args []float64) (float64, bool) { return fold(args, math.Min) },
+	"max":   func(args []float64) (float64, bool) { return fold(args, math.Max) },
+	"round": func(args []float64) (float64, bool) { return unary(args, math.Round) },
+	"floor": func(args []float64) (float64, bool) { return unary(args, math.Floor) },
+	"ceil":  func(args []float64) (float64, bool) { return unary(args, math.Ceil) },
+}
+
+// fold применяет функцию двух аргументов ко всем аргументам по очереди
+func fold(args []float64, f func(a, b float64) float64) (float64, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	result := args[0]
+	for _, arg := range args[1:] {
+		result = f(result, arg)
+	}
+	return result, true
+}
+
+// unary применяет функцию одного аргумента
+func unary(args []float64, f func(float64) float64) (float64, bool) {
+	if len(args) != 1 {
+		return 0, false
+	}
+	return f(args[0]), true
+}
+
+func (p *expressionParser) parse() (float64, error) {
+	result, err := p.expression()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpaces()
+	if p.position < len(p.text) {
+		return 0, p.unexpected()
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, fmt.Errorf("%s", T("template.notFinite"))
+	}
+	return result, nil
+}
+
+func (p *expressionParser) expression() (float64, error) {
+	result, err := p.term()
+	for err == nil {
+		switch p.peek() {
+		case '+':
+			p.position++
+			var right float64
+			right, err = p.term()
+			result += right
+		case '-':
+			p.position++
+			var right float64
+			right, err = p.term()
+			result -= right
+		default:
+			return result, nil
+		}
+	}
+	return 0, err
+}
+
+func (p *expressionParser) term() (float64, error) {
+	result, err := p.factor()
+	for err == nil {
+		switch p.peek() {
+		case '*':
+			p.position++
+			var right float64
+			right, err = p.factor()
+			result *= right
+		case '/':
+			p.position++
+			var right float64
+			right, err = p.factor()
+			if err == nil && right == 0 {
+				err = fmt.Errorf("%s", T("template.divisionByZero"))
+			}
+			result /= right
+		default:
+			return result, nil
+		}
+	}
+	return 0, err
+}
+
+func (p *expressionParser) factor() (float64, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxExpressionDepth {
+		return 0, fmt.Errorf("%s", T("template.tooDeep", "Depth", maxExpressionDepth))
+	}
+
+	switch r := p.peek(); {
+	case r == '-':
+		p.position++
+		value, err := p.factor()
+		return -value, err
+	case r == '(':
+		p.position++
+		value, err := p.expression()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, p.unexpected()
+		}
+		p.position++
+		return value, nil
+	case r == '$':
+		return p.variable()
+	case unicode.IsDigit(r) || r == '.':
+		return p.number()
+	case unicode.IsLetter(r):
+		return p.function()
+	default:
+		return 0, p.unexpected()
+	}
+}
+
+// variable читает ссылку ${имя}; значение переменной должно быть числом
+func (p *expressionParser) variable() (float64, error) {
+	match := templateVariablePattern.FindStringSubmatchIndex(string(p.text[p.position:]))
+	if match == nil || match[0] != 0 {
+		return 0, p.unexpected()
+	}
+	reference := string(p.text[p.position:])[:match[1]]
+	name := reference[2 : len(reference)-1]
+	p.position += len([]rune(reference))
+
+	value, ok := p.variables[name]
+	if !ok {
+		return 0, fmt.Errorf("%s", T("template.unknownVariable", "Name", name))
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s", T("template.notNumber", "Name", name, "Value", value))
+	}
+	return number, nil
+}
+
+func (p *expressionParser) number() (float64, error) {
+	start := p.position
+	for p.position < len(p.text) && (unicode.IsDigit(p.text[p.position]) || p.text[p.position] == '.') {
+		p.position++
+	}
+	value, err := strconv.ParseFloat(string(p.text[start:p.position]), 64)
+	if err != nil {
+		p.position = start
+		return 0, p.unexpected()
+	}
+	return value, nil
+}
+
+func (p *expressionParser) function() (float64, error) {
+	start := p.position
+	for p.position < len(p.text) && unicode.IsLetter(p.text[p.position]) {
+		p.position++
+	}
+	name := string(p.text[start:p.position])
+	call, ok := templateFunctions[name]
+	if !ok {
+		return 0, fmt.Errorf("%s", T("template.unknownFunction", "Name", name))
+	}
+	if p.peek() != '(' {
+		return 0, p.unexpected()
+	}
+	p.position++
+
+	var args []float64
+	for {
+		arg, err := p.expression()
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, arg)
+		if p.peek() == ',' {
+			p.position++
+			continue
+		}
+		if p.peek() != ')' {
+			return 0, p.unexpected()
+		}
+		p.position++
+		break
+	}
+	result, ok := call(args)
+	if !ok {
+		return 0, fmt.Errorf("%s", T("template.badArguments", "Name", name, "Count", len(args)))
+	}
+	return result, nil
+}
+
+// peek возвращает следующий символ после пробелов или 0 в конце выражения
+func (p *expressionParser) peek() rune {
+	p.skipSpaces()
+	if p.position >= len(p.text) {
+		return 0
+	}
+	return p.text[p.position]
+}
+
+func (p *expressionParser) skipSpaces() {
+	for p.position < len(p.text) && unicode.IsSpace(p.text[p.position]) {
+		p.position++
+	}
+}
+
+// unexpected возвращает ошибку о символе в текущей позиции
+func (p *expressionParser) unexpected() error {
+	if p.position >= len(p.text) {
+		return fmt.Errorf("%s", T("template.unexpectedEnd"))
+	}
+	return fmt.Errorf("%s", T("template.unexpected", "Text", string(p.text[p.position:]), "Position", p.position+1))
+}
+
+// checkTemplate проверяет значение с переменными при известных значениях по умолчанию. Если значения
+// есть у всех переменных, возвращает результат и complete = true. Иначе переменные без значений
+// заменяются единицей, чтобы проверить хотя бы запись выражения.
+func checkTemplate(value string, defaults map[string]string) (resolved string, complete bool, err error) {
+	variables := make(map[string]string, len(defaults))
+	for name, variable := range defaults {
+		variables[name] = variable
+	}
+	complete = true
+	for _, name := range templateNames(value) {
+		if _, ok := variables[name]; !ok {
+			variables[name] = "1"
+			complete = false
+		}
+	}
+	resolved, err = evalTemplate(value, variables)
+	return resolved, complete, err
+}
+
+// readPresetVariables читает объявления переменных пресета вместе с объявлениями его родителей
+// (объявление потомка важнее); для нечитаемых файлов возвращает nil
+func readPresetVariables(path string) map[string]PresetVariable {
+	chain, err := loadPresetChain(path)
+	if err != nil {
+		return nil
+	}
+	var variables map[string]PresetVariable
+	for i := len(chain) - 1; i >= 0; i-- {
+		for name, variable := range chain[i].Variables {
+			if variables == nil {
+				variables = make(map[string]PresetVariable)
+			}
+			variables[name] = variable
+		}
+	}
+	return variables
+}
+
+// loadPresetTemplate читает блоки пресета из файла и подставляет переменные шаблона:
+// values важнее значений по умолчанию из объявлений в файле
+func loadPresetTemplate(path string, values map[string]string) (map[string]PresetBlock, error) {
+	blocks, err := LoadPresetBlocks(path)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]string)
+	for name, variable := range readPresetVariables(path) {
+		if variable.Default != "" {
+			merged[name] = variable.Default
+		}
+	}
+	for name, value := range values {
+		merged[name] = value
+	}
+
+	var missing []string
+	var problems []ValueProblem
+	for _, block := range blocks {
+		for key, value := range block.Values {
+			if !isTemplate(value) {
+				continue
+			}
+			var absent []string
+			for _, name := range templateNames(value) {
+				if _, ok := merged[name]; !ok {
+					absent = append(absent, name)
+				}
+			}
+			if len(absent) > 0 {
+				missing = append(missing, absent...)
+				continue
+			}
+			result, err := evalTemplate(value, merged)
+			if err != nil {
+				problems = append(problems, ValueProblem{Section: block.Header, Key: key, Value: value, Reason: err.Error()})
+				continue
+			}
+			block.Values[key] = result
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &InvalidPresetError{Path: path, Problems: problems}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, &MissingVariablesError{Path: path, Names: appendUnique(nil, missing...)}
+	}
+	return blocks, nil
+}
+
+// PresetVariables возвращает переменные, на которые ссылаются пресеты всех семейств с этим именем,
+// по алфавиту, с описаниями и значениями по умолчанию из объявлений
+func PresetVariables(presetName string) ([]PresetVariable, error) {
+	families := presetFamiliesOf(presetName)
+	if len(families) == 0 {
+		return nil, fmt.Errorf("%s", T("error.presetNotFound", "Name", presetName))
+	}
+
+	found := make(map[string]PresetVariable)
+	for _, family := range families {
+		path := family.Path(presetName)
+		blocks, err := LoadPresetBlocks(path)
+		if err != nil {
+			return nil, err
+		}
+		declared := readPresetVariables(path)
+		for _, block := range blocks {
+			for _, value := range block.Values {
+				for _, name := range templateNames(value) {
+					variable := declared[name]
+					variable.Name = name
+					found[name] = variable
+				}
+			}
+		}
+	}
+
+	variables := make([]PresetVariable, 0, len(found))
+	for _, variable := range found {
+		variables = append(variables, variable)
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+	return variables, nil
+}
+
+// TemplateValues возвращает значения переменных шаблонов профиля: variables из config.json,
+// поверх них - значения текущего запуска (флаг --var, ответы на запрос)
+func (p *Profile) TemplateValues() map[string]string {
+	values := make(map[string]string, len(p.Variables)+len(p.sessionVariables))
+	for name, value := range p.Variables {
+		values[name] = value
+	}
+	for name, value := range p.sessionVariables {
+		values[name] = value
+	}
+	return values
+}
+
+// SetVariable задает значение переменной шаблонов до конца запуска; в config.json оно не пишется
+func (p *Profile) SetVariable(name, value string) {
+	if p.sessionVariables == nil {
+		p.sessionVariables = make(map[string]string)
+	}
+	p.sessionVariables[name] = value
+}
+
+// AskPresetVariables спрашивает значения переменных пресета, которых нет ни в профиле, ни в значениях
+// по умолчанию, и запоминает ответы в профиле до конца запуска
+func AskPresetVariables(profile *Profile, presetName string, p Prompter) error {
+	variables, err := PresetVariables(presetName)
+	if err != nil {
+		return err
+	}
+	values := profile.TemplateValues()
+	var missing []PresetVariable
+	for _, variable := range variables {
+		if _, ok := values[variable.Name]; !ok && variable.Default == "" {
+			missing = append(missing, variable)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	answers, err := p.AskVariables(presetName, missing)
+	if err != nil {
+		return err
+	}
+	for name, value := range answers {
+		profile.SetVariable(name, value)
+	}
+	return nil
+}
+
+// ParseVariable разбирает значение флага --var вида "имя=значение"
+func ParseVariable(text string) (string, string, error) {
+	name, value, ok := strings.Cut(text, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || !variableNamePattern.MatchString(name) {
+		return "", "", fmt.Errorf("%s", T("template.badVariable", "Value", text))
+	}
+	return name, strings.TrimSpace(value), nil
+}
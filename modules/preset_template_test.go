@@ -0,0 +1,122 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalTemplate(t *testing.T) {
+	variables := map[string]string{"base": "100", "low": "0.25", "mask": "true", "word": "high"}
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"одна ссылка как есть", "${mask}", "true"},
+		{"строка без вычисления", " ${word} ", "high"},
+		{"число", "42", "42"},
+		{"умножение раньше сложения", "2+3*4", "14"},
+		{"скобки", "(2+3)*4", "20"},
+		{"вычитание слева направо", "10-4-3", "3"},
+		{"деление слева направо", "64/4/2", "8"},
+		{"унарный минус", "-2*-3", "6"},
+		{"переменные", "${base}+${low}*4", "101"},
+		{"функции", "max(1, ${low}, min(7, 3)) + round(2.5) + floor(1.9) + ceil(0.1)", "8"},
+		{"без хвостов плавающей точки", "0.1+0.2", "0.3"},
+		{"пробелы", "  ( 1 + 2 ) * 3  ", "9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalTemplate(tt.value, variables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("%q = %q, ожидалось %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalTemplateErrors(t *testing.T) {
+	variables := map[string]string{"zero": "0", "word": "high"}
+	tests := []struct {
+		name  string
+		value string
+		want  string // Сообщение, которое должна содержать ошибка
+	}{
+		{"деление на ноль", "1/(2-2)", T("template.divisionByZero")},
+		{"деление на переменную с нулем", "5/${zero}", T("template.divisionByZero")},
+		{"неизвестная функция", "sqrt(4)", T("template.unknownFunction", "Name", "sqrt")},
+		{"вызов без скобок", "min 1", T("template.unexpected", "Text", "1", "Position", 5)},
+		{"неверное число аргументов", "round(1, 2)", T("template.badArguments", "Name", "round", "Count", 2)},
+		{"неизвестная переменная", "${missing}+1", T("template.unknownVariable", "Name", "missing")},
+		{"неизвестная одиночная переменная", "${missing}", T("template.unknownVariable", "Name", "missing")},
+		{"переменная не число", "${word}*2", T("template.notNumber", "Name", "word", "Value", "high")},
+		{"незакрытая скобка", "(1+2", T("template.unexpectedEnd")},
+		{"лишний символ", "1+2)", T("template.unexpected", "Text", ")", "Position", 4)},
+		{"два числа подряд", "1 2", T("template.unexpected", "Text", "2", "Position", 3)},
+		{"неверное число", "1.2.3", T("template.unexpected", "Text", "1.2.3", "Position", 1)},
+		{"пустое выражение", "", T("template.unexpectedEnd")},
+		{"код вместо выражения", "os.Exit(1)", T("template.unknownFunction", "Name", "os")},
+		{"незакрытая ссылка", "${base+1", T("template.unexpected", "Text", "${base+1", "Position", 1)},
+		{"глубокие скобки", strings.Repeat("(", maxExpressionDepth+1) + "1" + strings.Repeat(")", maxExpressionDepth+1), T("template.tooDeep", "Depth", maxExpressionDepth)},
+		{"много унарных минусов", strings.Repeat("-", 100000) + "1", T("template.tooDeep", "Depth", maxExpressionDepth)},
+		{"глубокие вызовы", strings.Repeat("min(", maxExpressionDepth) + "1" + strings.Repeat(")", maxExpressionDepth), T("template.tooDeep", "Depth", maxExpressionDepth)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalTemplate(tt.value, variables)
+			if err == nil {
+				t.Fatalf("%q = %q, ожидалась ошибка", tt.value, got)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ошибка %q, ожидалось сообщение %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalTemplateDepthLimit(t *testing.T) {
+	nested := strings.Repeat("(", maxExpressionDepth-1) + "1" + strings.Repeat(")", maxExpressionDepth-1)
+	if got, err := evalTemplate(nested, nil); err != nil || got != "1" {
+		t.Errorf("вложенность в пределах лимита: %q, %v", got, err)
+	}
+}
+
+func TestCheckTemplate(t *testing.T) {
+	resolved, complete, err := checkTemplate("${base}+${offset}", map[string]string{"base": "10"})
+	if err != nil || complete || resolved != "11" {
+		t.Errorf("без значения offset: %q, complete=%v, %v", resolved, complete, err)
+	}
+	resolved, complete, err = checkTemplate("${base}+${offset}", map[string]string{"base": "10", "offset": "5"})
+	if err != nil || !complete || resolved != "15" {
+		t.Errorf("со всеми значениями: %q, complete=%v, %v", resolved, complete, err)
+	}
+}
+
+func TestParseVariable(t *testing.T) {
+	tests := []struct {
+		text  string
+		name  string
+		value string
+		ok    bool
+	}{
+		{"baseHeight=120", "baseHeight", "120", true},
+		{" _low = 0.5 ", "_low", "0.5", true},
+		{"texture=a=b", "texture", "a=b", true},
+		{"empty=", "empty", "", true},
+		{"noValue", "", "", false},
+		{"=5", "", "", false},
+		{"1st=5", "", "", false},
+		{"a-b=5", "", "", false},
+		{"x}${y=5", "", "", false},
+		{"имя=5", "", "", false},
+	}
+	for _, tt := range tests {
+		name, value, err := ParseVariable(tt.text)
+		if (err == nil) != tt.ok || name != tt.name || value != tt.value {
+			t.Errorf("ParseVariable(%q) = %q, %q, %v", tt.text, name, value, err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package modules
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultJitter - наибольшие отклонения числовых полей слота материалов для VaryPreset по умолчанию
+var DefaultJitter = map[string]float64{
+	"HeightLowLimit":      10,
+	"HeightHighLimit":     10,
+	"Probability":         10,
+	"VerticalUVMult":      0.1,
+	"SlopeThresholdIndex": 1,
+}
+
+// NewSeed возвращает случайное зерно для VaryPreset
+func NewSeed() int64 {
+	// Зерно не больше 2^31, чтобы его можно было без потерь записать в JSON и набрать вручную
+	return rand.Int63n(1 << 31)
+}
+
+// VaryPreset создает вариант пресета: числовые поля слотов материалов сдвигаются на случайную
+// величину не больше jitter[ключ] в обе стороны. Один и тот же пресет, зерно и jitter всегда дают
+// один и тот же вариант; зерно и отклонения записываются в метаданные варианта.
+// Значения остаются в допустимом диапазоне ключа, целые округляются, HeightLowLimit не превышает
+// HeightHighLimit. Дискретные поля (текстуры, действие на склоне) и логические не меняются.
+// Аргумент - имя локального пресета или путь к файлу, как в ComparePresets.
+func VaryPreset(nameOrPath string, seed int64, jitter map[string]float64) (*PresetFile, error) {
+	if err := validateJitter(jitter); err != nil {
+		return nil, err
+	}
+	blocks, err := loadPresetForCompare(nameOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Как и при смешивании, вариант - пресет биома: кисти растительности в него не попадают
+	for header := range blocks {
+		if ref, ok := kindOf(header); ok && !slices.Contains(BiomeFamily.Kinds, ref.Kind) {
+			delete(blocks, header)
+		}
+	}
+	file := newPresetFile("", blocks)
+	file.Metadata.Description = fmt.Sprintf("%s, seed %d", nameOrPath, seed)
+	file.Metadata.Source = "vary"
+	file.Metadata.Seed = &seed
+	file.Metadata.Jitter = jitter
+
+	// Случайные числа берутся в порядке слотов и ключей таблицы вида - от порядка в файле вариант не зависит
+	random := rand.New(rand.NewSource(seed))
+	for i, slot := range file.Slots {
+		ref, ok := kindOf(sectionHeader(slot.Path))
+		if !ok || ref.Kind != materialPairKind {
+			continue
+		}
+		values := make(map[string]string, len(slot.Values.Values))
+		for key, value := range slot.Values.Values {
+			values[key] = value
+		}
+		for _, spec := range materialPairKind.Keys {
+			amount := jitter[spec.Name]
+			if amount == 0 {
+				continue
+			}
+			offset := (random.Float64()*2 - 1) * amount
+			value, exists := values[spec.Name]
+			number, err := strconv.ParseFloat(value, 64)
+			if !exists || err != nil {
+				continue
+			}
+			values[spec.Name] = jitterValue(spec, number+offset)
+		}
+		keepHeightOrder(values)
+		file.Slots[i].Values = PresetValues{Keys: slot.Values.Keys, Values: values}
+	}
+
+	if err := validatePreset(nameOrPath, file.blocks(), BiomeFamily.Kinds); err != nil {
+		return nil, err
+	}
+	Logger.Debug("preset varied", "preset", nameOrPath, "seed", seed)
+	return file, nil
+}
+
+// jitterValue приводит сдвинутое значение к диапазону и типу ключа
+func jitterValue(spec keySpec, number float64) string {
+	if spec.Min != nil {
+		number = math.Max(number, *spec.Min)
+	}
+	if spec.Max != nil {
+		number = math.Min(number, *spec.Max)
+	}
+	if spec.Type == valueInt {
+		return strconv.Itoa(int(math.Round(number)))
+	}
+	return strconv.FormatFloat(math.Round(number*1e4)/1e4, 'f', -1, 64)
+}
+
+// keepHeightOrder не дает нижнему пределу высоты после сдвига подняться выше верхнего
+func keepHeightOrder(values map[string]string) {
+	low, errLow := strconv.ParseFloat(values["HeightLowLimit"], 64)
+	high, errHigh := strconv.ParseFloat(values["HeightHighLimit"], 64)
+	if errLow == nil && errHigh == nil && low > high {
+		values["HeightLowLimit"] = values["HeightHighLimit"]
+	}
+}
+
+// validateJitter проверяет, что отклонения заданы только для числовых полей слота и не отрицательны
+func validateJitter(jitter map[string]float64) error {
+	var problems []string
+	for key, amount := range jitter {
+		spec := materialPairKind.spec(key)
+		switch {
+		case spec == nil || spec.Type == valueBool || spec.Discrete:
+			problems = append(problems, T("vary.notNumeric", "Key", key))
+		case amount < 0 || math.IsNaN(amount) || math.IsInf(amount, 0):
+			problems = append(problems, T("vary.badAmount", "Key", key, "Value", amount))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrInvalidValue, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ParseJitter разбирает отклонения вида "HeightLowLimit=5,Probability=0" поверх DefaultJitter
+func ParseJitter(text string) (map[string]float64, error) {
+	jitter := make(map[string]float64, len(DefaultJitter))
+	for key, amount := range DefaultJitter {
+		jitter[key] = amount
+	}
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("%s", T("vary.badJitter", "Value", item))
+		}
+		jitter[strings.TrimSpace(key)] = amount
+	}
+	return jitter, nil
+}
+
+// JitterKeys возвращает числовые поля слота материалов, которые можно сдвигать, в порядке таблицы
+func JitterKeys() []string {
+	var keys []string
+	for _, spec := range materialPairKind.Keys {
+		if spec.Type != valueBool && !spec.Discrete {
+			keys = append(keys, spec.Name)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,80 @@
+package modules
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// varyTestPreset сохраняет пресет биома с двумя слотами и возвращает путь
+func varyTestPreset(t *testing.T) string {
+	t.Helper()
+	return writeTestPreset(t, t.TempDir(), "meadow", map[string]map[string]string{
+		"Tools/TerrainEdit/MaterialPairSlot1": {"HeightLowLimit": "40", "HeightHighLimit": "45", "Probability": "95", "VerticalUVMult": "1.5", "SelectedHorizontalTexture": "3"},
+		"Tools/TerrainEdit/MaterialPairSlot2": {"HeightLowLimit": "0", "HeightHighLimit": "100", "Probability": "5", "SlopeThresholdIndex": "2"},
+	})
+}
+
+// encodedVariant возвращает вариант в том виде, в каком он записывается в файл
+func encodedVariant(t *testing.T, path string, seed int64, jitter map[string]float64) []byte {
+	t.Helper()
+	file, err := VaryPreset(path, seed, jitter)
+	if err != nil {
+		t.Fatalf("зерно %d: %v", seed, err)
+	}
+	data, err := encodePresetFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestVaryPresetSameSeed(t *testing.T) {
+	path := varyTestPreset(t)
+	first := encodedVariant(t, path, 42, DefaultJitter)
+	if second := encodedVariant(t, path, 42, DefaultJitter); !bytes.Equal(first, second) {
+		t.Errorf("одно и то же зерно дало разные варианты:\n%s\n%s", first, second)
+	}
+	if other := encodedVariant(t, path, 43, DefaultJitter); bytes.Equal(first, other) {
+		t.Error("другое зерно дало тот же вариант")
+	}
+}
+
+func TestVaryPresetStaysInRange(t *testing.T) {
+	path := varyTestPreset(t)
+	jitter := map[string]float64{"HeightLowLimit": 1000, "HeightHighLimit": 1000, "Probability": 1000, "VerticalUVMult": 100}
+	probability := materialPairKind.spec("Probability")
+	for seed := int64(0); seed < 50; seed++ {
+		file, err := VaryPreset(path, seed, jitter)
+		if err != nil {
+			t.Fatalf("зерно %d: %v", seed, err)
+		}
+		for _, slot := range file.Slots {
+			values := slot.Values.Values
+			if number, err := strconv.ParseFloat(values["Probability"], 64); err != nil || number < *probability.Min || number > *probability.Max {
+				t.Errorf("зерно %d, %s: Probability = %q вне %v..%v", seed, slot.Path, values["Probability"], *probability.Min, *probability.Max)
+			}
+			low, _ := strconv.ParseFloat(values["HeightLowLimit"], 64)
+			high, _ := strconv.ParseFloat(values["HeightHighLimit"], 64)
+			if low > high {
+				t.Errorf("зерно %d, %s: HeightLowLimit %v выше HeightHighLimit %v", seed, slot.Path, low, high)
+			}
+			if values["SelectedHorizontalTexture"] != "" && values["SelectedHorizontalTexture"] != "3" {
+				t.Errorf("зерно %d: текстура изменена на %s", seed, values["SelectedHorizontalTexture"])
+			}
+		}
+		if *file.Metadata.Seed != seed || file.Metadata.Jitter["Probability"] != 1000 {
+			t.Errorf("зерно %d: метаданные %+v", seed, file.Metadata)
+		}
+	}
+}
+
+func TestVaryPresetBadJitter(t *testing.T) {
+	path := varyTestPreset(t)
+	for _, jitter := range []map[string]float64{{"Probability": -1}, {"SelectedHorizontalTexture": 2}, {"NoSuchKey": 1}} {
+		if _, err := VaryPreset(path, 1, jitter); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("jitter %v: ошибка %v, ожидалась ErrInvalidValue", jitter, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Режимы фильтра папок проектов в workspace/dlc
+const (
+	ProjectFilterRegex = "regex" // имя папки должно соответствовать регулярному выражению
+	ProjectFilterAll   = "all"   // подходят все папки
+	ProjectFilterList  = "list"  // явные списки включаемых и исключаемых папок
+)
+
+// Фильтр по умолчанию: проекты Community DLC начинаются на "paf"
+const defaultProjectPattern = "^paf"
+
+// ProjectFilter задает, какие папки в dlc считаются проектами
+type ProjectFilter struct {
+	Mode    string   `json:"mode,omitempty"`    // regex, all или list
+	Pattern string   `json:"pattern,omitempty"` // регулярное выражение для режима regex
+	Include []string `json:"include,omitempty"` // папки для режима list (пусто - все)
+	Exclude []string `json:"exclude,omitempty"` // папки, исключаемые в любом режиме
+}
+
+// ProjectInfo описывает найденный проект и его миры
+type ProjectInfo struct {
+	Name    string
+	Worlds  []string
+	ModTime time.Time
+}
+
+// Описание проекта для списков выбора
+func (p ProjectInfo) String() string {
+	worlds := T("project.noWorlds")
+	if len(p.Worlds) > 0 {
+		worlds = T("project.worlds", "Worlds", strings.Join(p.Worlds, ", "))
+	}
+	return T("project.summary", "Name", p.Name, "Worlds", worlds, "Time", p.ModTime.Format("2006-01-02 15:04"))
+}
+
+// matcher собирает функцию проверки имени папки по фильтру
+func (f ProjectFilter) matcher() (func(string) bool, error) {
+	excluded := make(map[string]bool)
+	for _, name := range f.Exclude {
+		excluded[strings.ToLower(name)] = true
+	}
+
+	switch f.Mode {
+	case ProjectFilterAll:
+		return func(name string) bool {
+			return !excluded[strings.ToLower(name)]
+		}, nil
+	case ProjectFilterList:
+		included := make(map[string]bool)
+		for _, name := range f.Include {
+			included[strings.ToLower(name)] = true
+		}
+		return func(name string) bool {
+			lower := strings.ToLower(name)
+			if excluded[lower] {
+				return false
+			}
+			return len(included) == 0 || included[lower]
+		}, nil
+	case "", ProjectFilterRegex:
+		pattern := f.Pattern
+		if pattern == "" {
+			pattern = defaultProjectPattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", T("error.projectPattern", "Pattern", pattern), err)
+		}
+		return func(name string) bool {
+			return re.MatchString(name) && !excluded[strings.ToLower(name)]
+		}, nil
+	default:
+		return nil, fmt.Errorf("%s", T("error.projectFilterMode", "Mode", f.Mode))
+	}
+}
+
+// ListProjects возвращает проекты из папки dlc, подходящие под фильтр.
+// Список отсортирован по времени изменения: самые свежие проекты первыми.
+func ListProjects(dlcPath string, filter ProjectFilter) ([]ProjectInfo, error) {
+	match, err := filter.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dlcPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.dlcRead"), err)
+	}
+
+	var projects []ProjectInfo
+	for _, entry := range entries {
+		if !entry.IsDir() || !match(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", T("error.projectRead", "Name", entry.Name()), err)
+		}
+
+		project := ProjectInfo{Name: entry.Name(), ModTime: info.ModTime()}
+		worlds, worldsModTime := listWorlds(filepath.Join(dlcPath, entry.Name()))
+		project.Worlds = worlds
+		if worldsModTime.After(project.ModTime) {
+			project.ModTime = worldsModTime
+		}
+		projects = append(projects, project)
+	}
+
+	sort.SliceStable(projects, func(i, j int) bool {
+		return projects[i].ModTime.After(projects[j].ModTime)
+	})
+	return projects, nil
+}
+
+// listWorlds ищет миры проекта (data/levels/<мир>/<мир>.w2w) и время последнего изменения среди них
+func listWorlds(projectPath string) ([]string, time.Time) {
+	var latest time.Time
+	levelsPath := filepath.Join(projectPath, "data", "levels")
+	entries, err := os.ReadDir(levelsPath)
+	if err != nil {
+		return nil, latest
+	}
+
+	var worlds []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(levelsPath, entry.Name(), entry.Name()+".w2w"))
+		if err != nil {
+			continue
+		}
+		worlds = append(worlds, entry.Name())
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return worlds, latest
+}
@@ -0,0 +1,140 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+)
+
+// Prompter описывает взаимодействие с пользователем при первичной настройке путей.
+// GUI реализует его через диалоги Fyne, CLI - через консоль.
+type Prompter interface {
+	// ChooseDirectory просит пользователя выбрать папку
+	ChooseDirectory(title string) (string, error)
+	// ChooseRedkit предлагает найденные установки REDkit и возвращает путь к файлу сессий
+	// выбранной установки; пустая строка означает, что пользователь укажет папку вручную
+	ChooseRedkit(installs []RedkitInstall) (string, error)
+	// ChooseProject просит выбрать один проект из нескольких найденных и возвращает его имя
+	ChooseProject(projects []ProjectInfo) (string, error)
+	// AskVariables просит значения переменных шаблона пресета и возвращает их по именам
+	AskVariables(presetName string, variables []PresetVariable) (map[string]string, error)
+}
+
+// ConsolePrompter запрашивает пути и проект через консоль
+type ConsolePrompter struct{}
+
+// ChooseDirectory запрашивает путь к папке вводом в консоли
+func (ConsolePrompter) ChooseDirectory(title string) (string, error) {
+	consoleFile, err := showConsole()
+	if err != nil {
+		return "", err
+	}
+	defer releaseConsole(consoleFile)
+
+	for {
+		input := strings.TrimSpace(prompt.Input(title+": ", completer))
+		if input == "" {
+			continue
+		}
+		if !FileExists(input) {
+			fmt.Fprintln(consoleFile, T("console.folderNotFound", "Path", input))
+			continue
+		}
+		return input, nil
+	}
+}
+
+// ChooseRedkit выводит найденные установки и запрашивает номер; 0 - указать папку вручную
+func (ConsolePrompter) ChooseRedkit(installs []RedkitInstall) (string, error) {
+	consoleFile, err := showConsole()
+	if err != nil {
+		return "", err
+	}
+	defer releaseConsole(consoleFile)
+
+	fmt.Fprintln(consoleFile, T("console.redkitFound"))
+	for i, install := range installs {
+		fmt.Fprintf(consoleFile, "%d. %s\n", i+1, install)
+	}
+	fmt.Fprintln(consoleFile, "0. "+T("console.manualFolder"))
+
+	for {
+		input := prompt.Input(T("console.chooseInstall")+" ", completer)
+		choice, err := strconv.Atoi(input)
+		if err != nil || choice < 0 || choice > len(installs) {
+			fmt.Fprintln(consoleFile, T("console.badInstall"))
+			continue
+		}
+		if choice == 0 {
+			return "", nil
+		}
+		return installs[choice-1].SessionsPath, nil
+	}
+}
+
+// ChooseProject выводит список проектов и запрашивает номер в консоли
+func (ConsolePrompter) ChooseProject(projects []ProjectInfo) (string, error) {
+	// Открываем консольное окно для взаимодействия
+	consoleFile, err := showConsole()
+	if err != nil {
+		return "", err
+	}
+	defer releaseConsole(consoleFile) // Закрываем консоль в конце функции
+
+	fmt.Fprintln(consoleFile, T("console.projectsFound"))
+	for i, project := range projects {
+		fmt.Fprintf(consoleFile, "%d. %s\n", i+1, project)
+	}
+
+	for {
+		input := prompt.Input(T("console.chooseProject")+" ", completer)
+		choice, err := strconv.Atoi(input)
+		if err != nil || choice < 1 || choice > len(projects) {
+			fmt.Fprintln(consoleFile, T("console.badProject"))
+			continue
+		}
+
+		return projects[choice-1].Name, nil
+	}
+}
+
+// AskVariables запрашивает значение каждой переменной в консоли; пустой ввод не принимается
+func (ConsolePrompter) AskVariables(presetName string, variables []PresetVariable) (map[string]string, error) {
+	consoleFile, err := showConsole()
+	if err != nil {
+		return nil, err
+	}
+	defer releaseConsole(consoleFile)
+
+	fmt.Fprintln(consoleFile, T("console.presetVariables", "Preset", presetName))
+	values := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		if variable.Description != "" {
+			fmt.Fprintln(consoleFile, variable.Description)
+		}
+		for {
+			input := strings.TrimSpace(prompt.Input(variable.Name+": ", completer))
+			if input != "" {
+				values[variable.Name] = input
+				break
+			}
+		}
+	}
+	return values, nil
+}
+
+func completer(d prompt.Document) []prompt.Suggest {
+	// Здесь можно добавить автодополнение, если нужно
+	return []prompt.Suggest{}
+}
+
+// releaseConsole закрывает файл консоли и освобождает саму консоль
+func releaseConsole(consoleFile *os.File) {
+	consoleFile.Close()
+	if err := closeConsole(); err != nil {
+		Logger.Warn("failed to free console", "error", err)
+	}
+}
@@ -0,0 +1,390 @@
+package modules
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Имя папки установки REDkit и признак в названии игры у Steam/GOG
+const (
+	redkitFolderName = "The Witcher 3 REDkit"
+	redkitNameMarker = "redkit"
+	sessionsFileName = "r4LavaEditor2.sessions.ini"
+)
+
+// Источники, в которых найдена установка REDkit
+const (
+	RedkitSourceSteam  = "steam"
+	RedkitSourceGOG    = "gog"
+	RedkitSourceCommon = "common"
+)
+
+// RedkitInstall описывает найденную установку REDkit
+type RedkitInstall struct {
+	Path           string // Корневая папка установки
+	SessionsPath   string // Путь к bin/r4LavaEditor2.sessions.ini
+	SessionsExists bool   // Файл сессий уже создан редактором
+	Version        string // Версия или номер сборки, если удалось определить
+	Source         string // steam, gog или common
+}
+
+// Описание установки для списков выбора
+func (r RedkitInstall) String() string {
+	version := r.Version
+	if version == "" {
+		version = T("redkit.versionUnknown")
+	}
+	return fmt.Sprintf("%s [%s, %s]", r.Path, r.Source, version)
+}
+
+// DiscoveryOptions задает, где искать установки. Для тестов можно указать папки поддельного дерева.
+type DiscoveryOptions struct {
+	SteamRoots  []string // Папки Steam, содержащие steamapps/libraryfolders.vdf
+	GOGRoots    []string // Папки библиотек GOG и GOG Galaxy с установленными играми
+	CommonRoots []string // Папки, в которых может лежать "The Witcher 3 REDkit"
+}
+
+// DefaultDiscoveryOptions возвращает стандартные места установки для текущей ОС,
+// включая префиксы Wine/Proton на Linux
+func DefaultDiscoveryOptions() DiscoveryOptions {
+	var opts DiscoveryOptions
+	home, _ := os.UserHomeDir()
+
+	var windowsRoots []string
+	switch runtime.GOOS {
+	case "windows":
+		windowsRoots = []string{""}
+		for _, drive := range []string{"C:", "D:", "E:", "F:"} {
+			opts.CommonRoots = append(opts.CommonRoots, drive+`\Games`, drive+`\SteamLibrary\steamapps\common`, drive+`\GOG Games`)
+			opts.GOGRoots = append(opts.GOGRoots, drive+`\GOG Games`)
+		}
+	case "darwin":
+		opts.SteamRoots = append(opts.SteamRoots, filepath.Join(home, "Library", "Application Support", "Steam"))
+	default:
+		opts.SteamRoots = append(opts.SteamRoots,
+			filepath.Join(home, ".steam", "steam"),
+			filepath.Join(home, ".local", "share", "Steam"),
+			filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+		)
+		opts.GOGRoots = append(opts.GOGRoots, filepath.Join(home, "GOG Games"), filepath.Join(home, "Games"))
+		opts.CommonRoots = append(opts.CommonRoots, filepath.Join(home, "Games"))
+		windowsRoots = []string{filepath.Join(home, ".wine", "drive_c")}
+	}
+
+	// Стандартные папки Program Files (на Linux - внутри префикса Wine)
+	for _, root := range windowsRoots {
+		programFiles := []string{os.Getenv("ProgramFiles(x86)"), os.Getenv("ProgramFiles")}
+		if root != "" {
+			programFiles = []string{filepath.Join(root, "Program Files (x86)"), filepath.Join(root, "Program Files")}
+		}
+		for _, dir := range programFiles {
+			if dir == "" {
+				continue
+			}
+			opts.SteamRoots = append(opts.SteamRoots, filepath.Join(dir, "Steam"))
+			opts.GOGRoots = append(opts.GOGRoots, filepath.Join(dir, "GOG Galaxy", "Games"))
+			opts.CommonRoots = append(opts.CommonRoots, dir)
+		}
+	}
+	return opts
+}
+
+// DiscoverRedkit ищет установки REDkit в библиотеках Steam, GOG и стандартных папках.
+// Каждая установка возвращается один раз, даже если найдена несколькими способами.
+func DiscoverRedkit(opts DiscoveryOptions) []RedkitInstall {
+	var installs []RedkitInstall
+	seen := make(map[string]bool)
+	add := func(install RedkitInstall) {
+		key := strings.ToLower(filepath.Clean(install.Path))
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		installs = append(installs, install)
+	}
+
+	for _, root := range opts.SteamRoots {
+		for _, install := range discoverSteam(root) {
+			add(install)
+		}
+	}
+	for _, root := range opts.GOGRoots {
+		for _, install := range discoverGOG(root) {
+			add(install)
+		}
+	}
+	for _, root := range opts.CommonRoots {
+		if install, ok := redkitInstallAt(filepath.Join(root, redkitFolderName), RedkitSourceCommon, ""); ok {
+			add(install)
+		}
+	}
+
+	// Сначала установки с уже созданным файлом сессий
+	sort.SliceStable(installs, func(i, j int) bool {
+		return installs[i].SessionsExists && !installs[j].SessionsExists
+	})
+	return installs
+}
+
+// redkitInstallAt проверяет, что в папке лежит REDkit (есть папка bin), и описывает установку
+func redkitInstallAt(path, source, version string) (RedkitInstall, bool) {
+	info, err := os.Stat(filepath.Join(path, "bin"))
+	if err != nil || !info.IsDir() {
+		return RedkitInstall{}, false
+	}
+
+	sessionsPath := filepath.Join(path, "bin", sessionsFileName)
+	if version == "" {
+		version = readVersionFile(path)
+	}
+	return RedkitInstall{
+		Path:           path,
+		SessionsPath:   sessionsPath,
+		SessionsExists: FileExists(sessionsPath),
+		Version:        version,
+		Source:         source,
+	}, true
+}
+
+// readVersionFile читает первую строку version.txt из корня установки или из bin
+func readVersionFile(path string) string {
+	for _, candidate := range []string{filepath.Join(path, "version.txt"), filepath.Join(path, "bin", "version.txt")} {
+		file, err := os.Open(candidate)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		version := ""
+		if scanner.Scan() {
+			version = strings.TrimSpace(scanner.Text())
+		}
+		file.Close()
+		if version != "" {
+			return version
+		}
+	}
+	return ""
+}
+
+// discoverSteam читает steamapps/libraryfolders.vdf и ищет REDkit в манифестах каждой библиотеки
+func discoverSteam(steamRoot string) []RedkitInstall {
+	libraries := []string{steamRoot}
+	if data, err := os.ReadFile(filepath.Join(steamRoot, "steamapps", "libraryfolders.vdf")); err == nil {
+		if root, err := parseVDF(string(data)); err == nil {
+			libraries = append(libraries, steamLibraryPaths(root)...)
+		}
+	}
+
+	var installs []RedkitInstall
+	for _, library := range libraries {
+		steamapps := filepath.Join(library, "steamapps")
+		manifests, _ := filepath.Glob(filepath.Join(steamapps, "appmanifest_*.acf"))
+		for _, manifestPath := range manifests {
+			data, err := os.ReadFile(manifestPath)
+			if err != nil {
+				continue
+			}
+			root, err := parseVDF(string(data))
+			if err != nil {
+				continue
+			}
+			app := vdfSection(root, "AppState")
+			name, _ := app["name"].(string)
+			installDir, _ := app["installdir"].(string)
+			if installDir == "" || !strings.Contains(strings.ToLower(name), redkitNameMarker) {
+				continue
+			}
+			buildID, _ := app["buildid"].(string)
+			version := ""
+			if buildID != "" {
+				version = "build " + buildID
+			}
+			if install, ok := redkitInstallAt(filepath.Join(steamapps, "common", installDir), RedkitSourceSteam, version); ok {
+				installs = append(installs, install)
+			}
+		}
+	}
+	return installs
+}
+
+// steamLibraryPaths извлекает пути библиотек из libraryfolders.vdf (новый и старый формат)
+func steamLibraryPaths(root map[string]interface{}) []string {
+	var paths []string
+	for _, value := range vdfSection(root, "libraryfolders") {
+		switch entry := value.(type) {
+		case map[string]interface{}:
+			if path, ok := entry["path"].(string); ok {
+				paths = append(paths, path)
+			}
+		case string:
+			// В старом формате значение - сразу путь: "1" "D:\\SteamLibrary"
+			if strings.ContainsAny(entry, `/\`) {
+				paths = append(paths, entry)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// goggameInfo - поля манифеста goggame-<id>.info, которые нам нужны
+type goggameInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	BuildID string `json:"buildId"`
+}
+
+// discoverGOG ищет в подпапках библиотеки GOG манифесты goggame-*.info с REDkit в названии
+func discoverGOG(gogRoot string) []RedkitInstall {
+	entries, err := os.ReadDir(gogRoot)
+	if err != nil {
+		return nil
+	}
+
+	var installs []RedkitInstall
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		gamePath := filepath.Join(gogRoot, entry.Name())
+		manifests, _ := filepath.Glob(filepath.Join(gamePath, "goggame-*.info"))
+		for _, manifestPath := range manifests {
+			data, err := os.ReadFile(manifestPath)
+			if err != nil {
+				continue
+			}
+			var info goggameInfo
+			if err := json.Unmarshal(data, &info); err != nil {
+				continue
+			}
+			if !strings.Contains(strings.ToLower(info.Name), redkitNameMarker) {
+				continue
+			}
+			version := info.Version
+			if version == "" && info.BuildID != "" {
+				version = "build " + info.BuildID
+			}
+			if install, ok := redkitInstallAt(gamePath, RedkitSourceGOG, version); ok {
+				installs = append(installs, install)
+			}
+			break
+		}
+	}
+	return installs
+}
+
+// vdfSection возвращает вложенную секцию VDF без учета регистра имени
+func vdfSection(root map[string]interface{}, name string) map[string]interface{} {
+	for key, value := range root {
+		if strings.EqualFold(key, name) {
+			if section, ok := value.(map[string]interface{}); ok {
+				return section
+			}
+		}
+	}
+	return nil
+}
+
+// parseVDF разбирает текстовый формат Valve KeyValues (libraryfolders.vdf, appmanifest_*.acf).
+// Значения - строки или вложенные map[string]interface{}.
+func parseVDF(text string) (map[string]interface{}, error) {
+	tokens, err := tokenizeVDF(text)
+	if err != nil {
+		return nil, err
+	}
+	pos := 0
+	root, err := parseVDFObject(tokens, &pos, false)
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func parseVDFObject(tokens []string, pos *int, nested bool) (map[string]interface{}, error) {
+	object := make(map[string]interface{})
+	for *pos < len(tokens) {
+		key := tokens[*pos]
+		*pos++
+		if key == "}" {
+			if !nested {
+				return nil, fmt.Errorf("%s", T("error.vdfExtraBrace"))
+			}
+			return object, nil
+		}
+		if *pos >= len(tokens) {
+			return nil, fmt.Errorf("%s", T("error.vdfMissingValue", "Key", strconv.Quote(key)))
+		}
+		value := tokens[*pos]
+		*pos++
+		if value == "{" {
+			child, err := parseVDFObject(tokens, pos, true)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = child
+		} else {
+			object[key] = value
+		}
+	}
+	if nested {
+		return nil, fmt.Errorf("%s", T("error.vdfUnclosedSection"))
+	}
+	return object, nil
+}
+
+// tokenizeVDF разбивает текст на строки в кавычках, слова и фигурные скобки, пропуская комментарии //
+func tokenizeVDF(text string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case c == '{' || c == '}':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			var sb strings.Builder
+			i++
+			for i < len(text) && text[i] != '"' {
+				if text[i] == '\\' && i+1 < len(text) {
+					i++
+					switch text[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(text[i])
+					}
+				} else {
+					sb.WriteByte(text[i])
+				}
+				i++
+			}
+			if i >= len(text) {
+				return nil, fmt.Errorf("%s", T("error.vdfUnclosedQuote"))
+			}
+			tokens = append(tokens, sb.String())
+			i++
+		default:
+			start := i
+			for i < len(text) && !strings.ContainsRune(" \t\r\n{}\"", rune(text[i])) {
+				i++
+			}
+			tokens = append(tokens, text[start:i])
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,130 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFile создает файл вместе с родительскими папками
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// vdfQuote записывает путь в кавычках VDF, экранируя обратные слэши путей Windows
+func vdfQuote(path string) string {
+	return `"` + strings.ReplaceAll(path, `\`, `\\`) + `"`
+}
+
+func TestDiscoverRedkitFakeTree(t *testing.T) {
+	root := t.TempDir()
+	steamRoot := filepath.Join(root, "Steam")
+	library := filepath.Join(root, "SteamLibrary")
+	gogRoot := filepath.Join(root, "GOG Games")
+	commonRoot := filepath.Join(root, "Games")
+
+	// Steam: вторая библиотека из libraryfolders.vdf, в ней REDkit и посторонняя игра
+	writeTestFile(t, filepath.Join(steamRoot, "steamapps", "libraryfolders.vdf"), `"libraryfolders"
+{
+	// Основная библиотека
+	"0" { "path" `+vdfQuote(steamRoot)+` }
+	"1" { "path" `+vdfQuote(library)+` }
+}`)
+	writeTestFile(t, filepath.Join(library, "steamapps", "appmanifest_2684660.acf"), `"AppState"
+{
+	"appid" "2684660"
+	"name" "The Witcher 3 REDkit"
+	"buildid" "42"
+	"installdir" "The Witcher 3 REDkit"
+}`)
+	writeTestFile(t, filepath.Join(library, "steamapps", "appmanifest_292030.acf"), `"AppState" { "name" "The Witcher 3: Wild Hunt" "installdir" "The Witcher 3" }`)
+	steamInstall := filepath.Join(library, "steamapps", "common", "The Witcher 3 REDkit")
+	writeTestFile(t, filepath.Join(steamInstall, "bin", sessionsFileName), "")
+	writeTestFile(t, filepath.Join(library, "steamapps", "common", "The Witcher 3", "bin", "witcher3.exe"), "")
+
+	// GOG: манифест goggame-*.info, файла сессий еще нет
+	gogInstall := filepath.Join(gogRoot, "REDkit")
+	writeTestFile(t, filepath.Join(gogInstall, "goggame-1234.info"), `{"name": "The Witcher 3 REDkit", "version": "1.0.2"}`)
+	if err := os.MkdirAll(filepath.Join(gogInstall, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Стандартная папка: версия из version.txt; папка без bin установкой не считается
+	commonInstall := filepath.Join(commonRoot, redkitFolderName)
+	writeTestFile(t, filepath.Join(commonInstall, "version.txt"), "0.9\n")
+	if err := os.MkdirAll(filepath.Join(commonInstall, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(root, "Empty", redkitFolderName, "readme.txt"), "")
+
+	installs := DiscoverRedkit(DiscoveryOptions{
+		SteamRoots: []string{steamRoot},
+		GOGRoots:   []string{gogRoot},
+		// Установка Steam найдена и как стандартная папка - в результате она должна быть один раз
+		CommonRoots: []string{filepath.Join(library, "steamapps", "common"), commonRoot, filepath.Join(root, "Empty")},
+	})
+
+	want := []RedkitInstall{
+		{Path: steamInstall, SessionsPath: filepath.Join(steamInstall, "bin", sessionsFileName), SessionsExists: true, Version: "build 42", Source: RedkitSourceSteam},
+		{Path: gogInstall, SessionsPath: filepath.Join(gogInstall, "bin", sessionsFileName), Version: "1.0.2", Source: RedkitSourceGOG},
+		{Path: commonInstall, SessionsPath: filepath.Join(commonInstall, "bin", sessionsFileName), Version: "0.9", Source: RedkitSourceCommon},
+	}
+	if len(installs) != len(want) {
+		t.Fatalf("найдено %d установок, ожидалось %d: %v", len(installs), len(want), installs)
+	}
+	for i := range want {
+		if installs[i] != want[i] {
+			t.Errorf("установка %d: %+v, ожидалось %+v", i, installs[i], want[i])
+		}
+	}
+}
+
+func TestDiscoverRedkitMissingRoots(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	installs := DiscoverRedkit(DiscoveryOptions{SteamRoots: []string{missing}, GOGRoots: []string{missing}, CommonRoots: []string{missing}})
+	if len(installs) != 0 {
+		t.Errorf("в несуществующих папках найдены установки: %v", installs)
+	}
+}
+
+func TestSteamLibraryPathsOldFormat(t *testing.T) {
+	root, err := parseVDF(`"LibraryFolders" { "TimeNextStatsReport" "1700000000" "1" "D:\\SteamLibrary" }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := steamLibraryPaths(root)
+	if len(paths) != 1 || paths[0] != `D:\SteamLibrary` {
+		t.Errorf("пути библиотек: %q", paths)
+	}
+}
+
+func TestParseVDFErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		id   string
+	}{
+		{"лишняя скобка", `"a" "b" }`, "error.vdfExtraBrace"},
+		{"нет значения", `"a" "x" "b"`, "error.vdfMissingValue"},
+		{"не закрыта секция", `"a" { "b" "c"`, "error.vdfUnclosedSection"},
+		{"не закрыта кавычка", `"a" "b`, "error.vdfUnclosedQuote"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseVDF(tt.text)
+			if err == nil {
+				t.Fatal("ошибки нет")
+			}
+			if want := T(tt.id, "Key", `"b"`); err.Error() != want {
+				t.Errorf("ошибка %q, ожидалось %q", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package modules
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// JSON-схемы конфигурации и форматов, встроенные в приложение
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// jsonSchema - подмножество JSON Schema (draft-07), которого достаточно для наших файлов
+type jsonSchema struct {
+	Type                 schemaType             `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *additionalProperties  `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+}
+
+// schemaType - значение type: имя типа или список допустимых типов, например ["string", "number"]
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = schemaType{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*t = names
+	return nil
+}
+
+// matches сообщает, подходит ли значение под один из типов
+func (t schemaType) matches(value interface{}) bool {
+	for _, name := range t {
+		if schemaTypeMatches(name, value) {
+			return true
+		}
+	}
+	return len(t) == 0
+}
+
+func (t schemaType) String() string {
+	return strings.Join(t, " | ")
+}
+
+// additionalProperties - значение additionalProperties: либо true/false, либо схема для значений
+// полей, не перечисленных в properties (например, словарь строк)
+type additionalProperties struct {
+	Allowed bool
+	Schema  *jsonSchema
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Allowed); err == nil {
+		return nil
+	}
+	a.Allowed = true
+	a.Schema = &jsonSchema{}
+	return json.Unmarshal(data, a.Schema)
+}
+
+// SchemaError описывает одно нарушение схемы с путем к полю, например profiles[0].backup.keep
+type SchemaError struct {
+	Field   string
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// SchemaErrors - все нарушения схемы, найденные в документе
+type SchemaErrors []SchemaError
+
+func (e SchemaErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// loadSchema читает встроенную схему по имени файла
+func loadSchema(name string) (*jsonSchema, error) {
+	data, err := schemaFiles.ReadFile("schemas/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.schemaLoad", "Name", name), err)
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("%s: %v", T("error.schemaLoad", "Name", name), err)
+	}
+	return &schema, nil
+}
+
+// validateAgainstSchema проверяет документ, декодированный через encoding/json, по встроенной схеме
+func validateAgainstSchema(schemaName string, document interface{}) error {
+	schema, err := loadSchema(schemaName)
+	if err != nil {
+		return err
+	}
+
+	var errs SchemaErrors
+	schema.validate("", document, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s *jsonSchema) validate(path string, value interface{}, errs *SchemaErrors) {
+	fail := func(id string, args ...interface{}) {
+		*errs = append(*errs, SchemaError{Field: path, Message: T(id, args...)})
+	}
+
+	if !s.Type.matches(value) {
+		fail("schema.type", "Expected", s.Type.String(), "Actual", schemaTypeName(value))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		allowed := false
+		for _, option := range s.Enum {
+			if fmt.Sprint(option) == fmt.Sprint(value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			fail("schema.enum", "Value", value, "Allowed", s.Enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				fail("schema.required", "Name", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := joinSchemaPath(path, name)
+			if property, ok := s.Properties[name]; ok {
+				property.validate(child, v[name], errs)
+			} else if extra := s.AdditionalProperties; extra != nil && extra.Schema != nil {
+				extra.Schema.validate(child, v[name], errs)
+			} else if extra != nil && !extra.Allowed {
+				*errs = append(*errs, SchemaError{Field: child, Message: T("schema.unknownField")})
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			fail("schema.minimum", "Value", v, "Limit", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			fail("schema.maximum", "Value", v, "Limit", *s.Maximum)
+		}
+	case string:
+		if s.MinLength != nil && len(v) < *s.MinLength {
+			fail("schema.minLength", "Limit", *s.MinLength)
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+				fail("schema.pattern", "Value", v, "Pattern", s.Pattern)
+			}
+		}
+	}
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func schemaTypeMatches(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return schemaTypeName(value) == schemaType
+	}
+}
+
+func schemaTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,43 @@
+package modules
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSchemaTypedValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		doc    string
+		field  string // Поле с ошибкой; пусто - документ правильный
+	}{
+		{"переменные профиля", "config.schema.json", `{"version": 4, "profiles": [{"name": "main", "variables": {"height": "120"}}]}`, ""},
+		{"число в переменных профиля", "config.schema.json", `{"version": 4, "profiles": [{"name": "main", "variables": {"height": 120}}]}`, "profiles[0].variables.height"},
+		{"значения слота", "preset.schema.json", `{"formatVersion": 1, "slots": [{"path": "Tools/TerrainEdit/MaterialPairSlot1", "values": {"Probability": 80, "VerticalMask": true, "HeightLowLimit": "${low}"}}]}`, ""},
+		{"объект в значениях слота", "preset.schema.json", `{"formatVersion": 1, "slots": [{"path": "Tools/TerrainEdit/MaterialPairSlot1", "values": {"Probability": {"value": 80}}}]}`, "slots[0].values.Probability"},
+		{"отрицательный jitter", "preset.schema.json", `{"formatVersion": 1, "metadata": {"jitter": {"Probability": -5}}, "slots": []}`, "metadata.jitter.Probability"},
+		{"переменная пресета", "preset.schema.json", `{"formatVersion": 1, "variables": {"low": {"description": "Нижняя граница", "default": 10}}, "slots": []}`, ""},
+		{"лишнее поле переменной", "preset.schema.json", `{"formatVersion": 1, "variables": {"low": {"value": 10}}, "slots": []}`, "variables.low.value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &document); err != nil {
+				t.Fatal(err)
+			}
+			err := validateAgainstSchema(tt.schema, document)
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("правильный документ не прошел проверку: %v", err)
+				}
+				return
+			}
+			var errs SchemaErrors
+			if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != tt.field {
+				t.Errorf("ошибка %v, ожидалась одна ошибка в поле %s", err, tt.field)
+			}
+		})
+	}
+}
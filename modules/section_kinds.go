@@ -0,0 +1,271 @@
+package modules
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Типы значений ключей секций
+const (
+	valueBool  = "bool"
+	valueInt   = "int"
+	valueFloat = "float"
+)
+
+// keySpec описывает один ключ секции: тип, допустимый диапазон и значение по умолчанию
+type keySpec struct {
+	Name     string
+	Type     string
+	Min      *float64 // nil - без ограничения
+	Max      *float64
+	Default  string // Пусто - ключ не дополняется
+	Discrete bool   // Число - номер варианта (текстура, действие), а не величина: при смешивании не усредняется
+}
+
+// bound упрощает запись диапазонов в таблицах ключей
+func bound(v float64) *float64 {
+	return &v
+}
+
+// SectionKind - вид секции инструмента мира: слоты материалов, настройки кистей, растительности и т.д.
+// Заголовок секции в пресете сопоставляется с видом по Pattern, первая группа - идентификатор
+// секции внутри мира (номер слота, имя инструмента).
+type SectionKind struct {
+	Name     string
+	Tool     string // Секция инструмента в мире; рядом с его секциями вставляются новые
+	Pattern  *regexp.Regexp
+	Numbered bool                   // Идентификаторы - номера, секции сортируются по числу
+	Keys     []keySpec              // Известные ключи в порядке записи
+	suffix   func(id string) string // Часть заголовка после .w2w/
+}
+
+// Слоты пар материалов: [...w2w/Tools/TerrainEdit/MaterialPairSlot<N>]
+var materialPairKind = &SectionKind{
+	Name:     "MaterialPairSlot",
+	Tool:     "Tools/TerrainEdit",
+	Pattern:  regexp.MustCompile(`/Tools/TerrainEdit/MaterialPairSlot(\d+)\]$`),
+	Numbered: true,
+	Keys: []keySpec{
+		{Name: "VerticalMask", Type: valueBool, Default: "false"},
+		{Name: "VerticalUVMult", Type: valueFloat, Min: bound(0), Default: "1"},
+		{Name: "VerticalUVScaleMask", Type: valueBool, Default: "false"},
+		{Name: "HeightLowLimit", Type: valueFloat, Default: "0"},
+		{Name: "Probability", Type: valueFloat, Min: bound(0), Max: bound(100), Default: "100"},
+		{Name: "PresetEnabled", Type: valueBool, Default: "false"},
+		{Name: "HighLimitMask", Type: valueBool, Default: "false"},
+		{Name: "HorizontalMask", Type: valueBool, Default: "false"},
+		{Name: "SelectedHorizontalTexture", Type: valueInt, Min: bound(0), Default: "0", Discrete: true},
+		{Name: "SlopeThresholdMask", Type: valueBool, Default: "false"},
+		{Name: "SelectedVerticalTexture", Type: valueInt, Min: bound(0), Default: "0", Discrete: true},
+		{Name: "LowLimitMask", Type: valueBool, Default: "false"},
+		{Name: "SlopeThresholdAction", Type: valueInt, Min: bound(0), Default: "0", Discrete: true},
+		{Name: "SlopeThresholdIndex", Type: valueInt, Min: bound(0), Default: "0"},
+		{Name: "HeightHighLimit", Type: valueFloat, Default: "0"},
+	},
+	suffix: func(id string) string { return "Tools/TerrainEdit/MaterialPairSlot" + id },
+}
+
+// Настройки инструментов и кистей: [...w2w/Tools/TerrainEdit] и [...w2w/Tools/TerrainEdit/<Инструмент>].
+// Ключи, которых нет в таблице, обрабатываются политикой неизвестных ключей профиля.
+var terrainToolKind = &SectionKind{
+	Name:    "TerrainEdit",
+	Tool:    "Tools/TerrainEdit",
+	Pattern: regexp.MustCompile(`/Tools/TerrainEdit(?:/(\w+))?\]$`),
+	Keys: []keySpec{
+		{Name: "Size", Type: valueFloat, Min: bound(0)},
+		{Name: "Intensity", Type: valueFloat, Min: bound(0)},
+		{Name: "Falloff", Type: valueFloat, Min: bound(0), Max: bound(1)},
+	},
+	suffix: func(id string) string {
+		if id == "" {
+			return "Tools/TerrainEdit"
+		}
+		return "Tools/TerrainEdit/" + id
+	},
+}
+
+// Кисти растительности: [...w2w/Tools/FoliageEdit] и [...w2w/Tools/FoliageEdit/<Кисть>]
+var foliageBrushKind = &SectionKind{
+	Name:    "FoliageEdit",
+	Tool:    "Tools/FoliageEdit",
+	Pattern: regexp.MustCompile(`/Tools/FoliageEdit(?:/(\w+))?\]$`),
+	Keys: []keySpec{
+		{Name: "Size", Type: valueFloat, Min: bound(0)},
+		{Name: "Density", Type: valueFloat, Min: bound(0)},
+		{Name: "MinScale", Type: valueFloat, Min: bound(0)},
+		{Name: "MaxScale", Type: valueFloat, Min: bound(0)},
+		{Name: "RandomRotation", Type: valueBool},
+		{Name: "AlignToTerrain", Type: valueBool},
+	},
+	suffix: func(id string) string {
+		if id == "" {
+			return "Tools/FoliageEdit"
+		}
+		return "Tools/FoliageEdit/" + id
+	},
+}
+
+// Реестр видов секций; заголовок относится к первому подходящему виду
+var sectionKinds = []*SectionKind{materialPairKind, terrainToolKind, foliageBrushKind}
+
+// sectionRef - секция мира, заданная видом и идентификатором, независимо от проекта в заголовке
+type sectionRef struct {
+	Kind *SectionKind
+	ID   string
+}
+
+// kindOf определяет вид секции по заголовку
+func kindOf(header string) (sectionRef, bool) {
+	header = strings.TrimSpace(header)
+	for _, kind := range sectionKinds {
+		if matches := kind.Pattern.FindStringSubmatch(header); matches != nil {
+			return sectionRef{Kind: kind, ID: matches[1]}, true
+		}
+	}
+	return sectionRef{}, false
+}
+
+// header формирует заголовок секции для мира проекта
+func (r sectionRef) header(projectName, worldName string) string {
+	return worldSectionPrefix(projectName, worldName) + r.Kind.suffix(r.ID) + "]"
+}
+
+// label возвращает короткое имя секции для отчетов: номер слота или имя инструмента
+func (r sectionRef) label() string {
+	if r.Kind.Numbered {
+		return r.ID
+	}
+	if r.ID == "" {
+		return r.Kind.Name
+	}
+	return r.ID
+}
+
+// less задает порядок секций: по порядку видов в реестре, затем по номеру или имени
+func (r sectionRef) less(other sectionRef) bool {
+	if r.Kind != other.Kind {
+		return kindIndex(r.Kind) < kindIndex(other.Kind)
+	}
+	if r.Kind.Numbered {
+		a, _ := strconv.Atoi(r.ID)
+		b, _ := strconv.Atoi(other.ID)
+		return a < b
+	}
+	return r.ID < other.ID
+}
+
+func kindIndex(kind *SectionKind) int {
+	if i := kindIndexIn(sectionKinds, kind); i >= 0 {
+		return i
+	}
+	return len(sectionKinds)
+}
+
+// kindIndexIn ищет вид в списке; -1 - не найден
+func kindIndexIn(kinds []*SectionKind, kind *SectionKind) int {
+	for i, registered := range kinds {
+		if registered == kind {
+			return i
+		}
+	}
+	return -1
+}
+
+// keyOrder возвращает известные ключи вида в порядке записи
+func (k *SectionKind) keyOrder() []string {
+	keys := make([]string, len(k.Keys))
+	for i, spec := range k.Keys {
+		keys[i] = spec.Name
+	}
+	return keys
+}
+
+// spec ищет описание ключа; nil - ключ неизвестен
+func (k *SectionKind) spec(key string) *keySpec {
+	for i := range k.Keys {
+		if k.Keys[i].Name == key {
+			return &k.Keys[i]
+		}
+	}
+	return nil
+}
+
+// isKnown сообщает, описан ли ключ в таблице вида
+func (k *SectionKind) isKnown(key string) bool {
+	return k.spec(key) != nil
+}
+
+// validateValue проверяет значение известного ключа по типу и диапазону; неизвестные ключи не проверяются
+func (k *SectionKind) validateValue(key, value string) error {
+	spec := k.spec(key)
+	if spec == nil {
+		return nil
+	}
+	if spec.Type == valueBool {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s", T("value.notBool"))
+		}
+		return nil
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return fmt.Errorf("%s", T("value.notNumber"))
+	}
+	if spec.Type == valueInt && number != math.Trunc(number) {
+		return fmt.Errorf("%s", T("value.notInteger"))
+	}
+	if spec.Min != nil && number < *spec.Min {
+		return fmt.Errorf("%s", T("schema.minimum", "Value", value, "Limit", *spec.Min))
+	}
+	if spec.Max != nil && number > *spec.Max {
+		return fmt.Errorf("%s", T("schema.maximum", "Value", value, "Limit", *spec.Max))
+	}
+	return nil
+}
+
+// presetSections раскладывает блоки пресета по секциям мира и возвращает их в порядке sectionRef.less.
+// Заголовки в пресете могут ссылаться на другой проект: значение имеют только вид и идентификатор.
+func presetSections(presetBlocks map[string]PresetBlock) (map[sectionRef]PresetBlock, []sectionRef) {
+	byRef := make(map[sectionRef]PresetBlock)
+	var refs []sectionRef
+	for header, block := range presetBlocks {
+		ref, ok := kindOf(header)
+		if !ok {
+			continue
+		}
+		if _, exists := byRef[ref]; !exists {
+			refs = append(refs, ref)
+		}
+		byRef[ref] = block
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].less(refs[j]) })
+	return byRef, refs
+}
+
+// validatePreset проверяет значения всех блоков пресета по таблицам их видов.
+// Если allowed не пусто, секции других видов считаются ошибкой (пресет не своего семейства).
+func validatePreset(path string, presetBlocks map[string]PresetBlock, allowed []*SectionKind) error {
+	byRef, refs := presetSections(presetBlocks)
+	var problems []ValueProblem
+	for _, ref := range refs {
+		block := byRef[ref]
+		if len(allowed) > 0 && kindIndexIn(allowed, ref.Kind) < 0 {
+			problems = append(problems, ValueProblem{Section: block.Header, Reason: T("value.kindNotAllowed", "Kind", ref.Kind.Name)})
+			continue
+		}
+		for _, key := range block.keys() {
+			if err := ref.Kind.validateValue(key, block.Values[key]); err != nil {
+				problems = append(problems, ValueProblem{Section: block.Header, Key: key, Value: block.Values[key], Reason: err.Error()})
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &InvalidPresetError{Path: path, Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// sessionDocument - файл сессий REDkit, разобранный на секции.
+// Строки, которые не меняются, записываются обратно без изменений.
+type sessionDocument struct {
+	preamble []string      // Строки до первой секции
+	sections []*iniSection // Секции в порядке следования в файле
+}
+
+// iniSection - одна секция [заголовок] с ключами в исходном порядке
+type iniSection struct {
+	Header string
+	Keys   []string          // Порядок ключей, как в файле
+	Values map[string]string // Значения ключей
+	Extra  []string          // Прочие строки секции (пустые строки, комментарии), пишутся после ключей
+}
+
+// parseSessionDocument разбирает текст файла сессий
+func parseSessionDocument(text string) *sessionDocument {
+	doc := &sessionDocument{}
+	var current *iniSection
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	// Завершающий перевод строки не дает отдельной пустой строки
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			current = &iniSection{Header: trimmed, Values: make(map[string]string)}
+			doc.sections = append(doc.sections, current)
+			continue
+		}
+		if current == nil {
+			doc.preamble = append(doc.preamble, line)
+			continue
+		}
+		if key, value, ok := splitKeyValue(line); ok {
+			if _, exists := current.Values[key]; !exists {
+				current.Keys = append(current.Keys, key)
+			}
+			current.Values[key] = value
+			continue
+		}
+		current.Extra = append(current.Extra, line)
+	}
+	return doc
+}
+
+// splitKeyValue разбирает строку "ключ=значение"
+func splitKeyValue(line string) (string, string, bool) {
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	key := strings.TrimSpace(parts[0])
+	if key == "" {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(parts[1]), true
+}
+
+// section ищет секцию по точному заголовку
+func (d *sessionDocument) section(header string) *iniSection {
+	for _, section := range d.sections {
+		if section.Header == header {
+			return section
+		}
+	}
+	return nil
+}
+
+// render собирает текст файла с DOS-переводами строк
+func (d *sessionDocument) render() string {
+	var sb strings.Builder
+	for _, line := range d.preamble {
+		sb.WriteString(line + "\r\n")
+	}
+	for _, section := range d.sections {
+		sb.WriteString(section.Header + "\r\n")
+		for _, key := range section.Keys {
+			sb.WriteString(fmt.Sprintf("%s=%s\r\n", key, section.Values[key]))
+		}
+		for _, line := range section.Extra {
+			sb.WriteString(line + "\r\n")
+		}
+	}
+	return sb.String()
+}
+
+// setKeys заменяет содержимое секции ключами в указанном порядке
+func (s *iniSection) setKeys(keys []string, values map[string]string) {
+	s.Keys = keys
+	s.Values = values
+}
+
+// value возвращает значение ключа; у отсутствующей секции (nil) ключей нет
+func (s *iniSection) value(key string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	value, ok := s.Values[key]
+	return value, ok
+}
+
+// set задает значение ключа; новый ключ дописывается в конец секции
+func (s *iniSection) set(key, value string) {
+	if _, exists := s.Values[key]; !exists {
+		s.Keys = append(s.Keys, key)
+	}
+	s.Values[key] = value
+}
+
+// removeKey удаляет ключ из секции
+func (s *iniSection) removeKey(key string) {
+	if _, exists := s.Values[key]; !exists {
+		return
+	}
+	delete(s.Values, key)
+	for i, k := range s.Keys {
+		if k == key {
+			s.Keys = append(s.Keys[:i:i], s.Keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// clone возвращает независимую копию документа (снимок до изменения)
+func (d *sessionDocument) clone() *sessionDocument {
+	copyDoc := &sessionDocument{preamble: append([]string(nil), d.preamble...)}
+	for _, section := range d.sections {
+		values := make(map[string]string, len(section.Values))
+		for key, value := range section.Values {
+			values[key] = value
+		}
+		copyDoc.sections = append(copyDoc.sections, &iniSection{
+			Header: section.Header,
+			Keys:   append([]string(nil), section.Keys...),
+			Values: values,
+			Extra:  append([]string(nil), section.Extra...),
+		})
+	}
+	return copyDoc
+}
+
+// insertAfter вставляет секцию сразу после секции с заголовком anchor;
+// если такой секции нет (или anchor пуст), секция ставится в начало
+func (d *sessionDocument) insertAfter(anchor string, section *iniSection) {
+	index := 0
+	for i, existing := range d.sections {
+		if existing.Header == anchor {
+			index = i + 1
+			break
+		}
+	}
+	d.sections = append(d.sections[:index:index], append([]*iniSection{section}, d.sections[index:]...)...)
+}
+
+// removeSection удаляет секцию с указанным заголовком
+func (d *sessionDocument) removeSection(header string) {
+	for i, section := range d.sections {
+		if section.Header == header {
+			d.sections = append(d.sections[:i:i], d.sections[i+1:]...)
+			return
+		}
+	}
+}
+
+// readSessionDocument читает файл сессий в кодировке Windows-1251
+func readSessionDocument(path string) (*sessionDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, path)
+		}
+		return nil, fmt.Errorf("%s: %w", T("error.sessionsRead", "Path", path), err)
+	}
+
+	text, err := decodeWindows1251(path, data)
+	if err != nil {
+		return nil, err
+	}
+	return parseSessionDocument(text), nil
+}
+
+// writeSessionDocument записывает файл сессий через временный файл, чтобы не оставить его наполовину записанным
+func writeSessionDocument(path string, doc *sessionDocument) error {
+	data, err := encodeWindows1251(path, doc.render())
+	if err != nil {
+		return err
+	}
+
+	tempFilePath := path + ".tmp"
+	if err := os.WriteFile(tempFilePath, data, 0644); err != nil {
+		return fmt.Errorf("%s: %w", T("error.tempWrite", "Path", tempFilePath), err)
+	}
+	if err := os.Rename(tempFilePath, path); err != nil {
+		os.Remove(tempFilePath)
+		return fmt.Errorf("%s: %w", T("error.sessionsReplace", "Path", path), err)
+	}
+	return nil
+}
+
+// decodeWindows1251 переводит содержимое файла в UTF-8. Файлы в UTF-16 не поддерживаются.
+func decodeWindows1251(path string, data []byte) (string, error) {
+	if bytes.HasPrefix(data, []byte{0xFF, 0xFE}) || bytes.HasPrefix(data, []byte{0xFE, 0xFF}) {
+		return "", &EncodingError{Path: path, Reason: T("encoding.utf16")}
+	}
+	if bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}) {
+		// Файл уже в UTF-8 (например, сохранен сторонним редактором)
+		return string(data[3:]), nil
+	}
+	decoded, err := charmap.Windows1251.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", &EncodingError{Path: path, Reason: err.Error()}
+	}
+	return string(decoded), nil
+}
+
+// encodeWindows1251 переводит текст в Windows-1251 и указывает строку с непредставимым символом
+func encodeWindows1251(path, text string) ([]byte, error) {
+	encoder := charmap.Windows1251.NewEncoder()
+	var out bytes.Buffer
+	for i, line := range strings.SplitAfter(text, "\n") {
+		encoded, err := encoder.String(line)
+		if err != nil {
+			return nil, &EncodingError{Path: path, Line: i + 1, Reason: T("encoding.unrepresentable", "Text", strings.TrimSpace(line))}
+		}
+		out.WriteString(encoded)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,119 @@
+package modules
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Что делать со слотами мира, которых нет в пресете
+const (
+	ExtraSlotsKeep    = "keep"    // Оставить как есть
+	ExtraSlotsReset   = "reset"   // Сбросить все ключи к значениям по умолчанию
+	ExtraSlotsDisable = "disable" // Только выключить слот (PresetEnabled=false)
+)
+
+// SlotSettings задает, как пресет применяется к секциям MaterialPairSlot мира
+type SlotSettings struct {
+	InsertMissing bool        `json:"insert_missing"`  // Создавать секции слотов, которых еще нет в файле сессий
+	Extra         string      `json:"extra,omitempty"` // Слоты мира сверх пресета: keep, reset, disable
+	Keys          KeySettings `json:"keys"`            // Значения по умолчанию и неизвестные ключи
+}
+
+// Настройки слотов для новых профилей
+func defaultSlotSettings() SlotSettings {
+	return SlotSettings{InsertMissing: true, Extra: ExtraSlotsKeep, Keys: defaultKeySettings()}
+}
+
+// ValidateExtraSlots проверяет режим обработки слотов сверх пресета (пусто - keep)
+func ValidateExtraSlots(mode string) error {
+	switch mode {
+	case "", ExtraSlotsKeep, ExtraSlotsReset, ExtraSlotsDisable:
+		return nil
+	}
+	return fmt.Errorf("%s", T("error.extraSlotsMode", "Mode", mode))
+}
+
+// worldSectionPrefix - начало заголовков всех секций мира проекта в файле сессий
+func worldSectionPrefix(projectName, worldName string) string {
+	return fmt.Sprintf("[Session/dlc\\%s\\data\\levels\\%s\\%s.w2w/", projectName, worldName, worldName)
+}
+
+// worldSlots возвращает секции MaterialPairSlot мира в порядке следования в файле
+func (d *sessionDocument) worldSlots(projectName, worldName string) []*iniSection {
+	prefix := worldSectionPrefix(projectName, worldName)
+	var slots []*iniSection
+	for _, section := range d.sections {
+		if !strings.HasPrefix(section.Header, prefix) {
+			continue
+		}
+		if ref, ok := kindOf(section.Header); ok && ref.Kind == materialPairKind {
+			slots = append(slots, section)
+		}
+	}
+	return slots
+}
+
+// insertSection добавляет секцию мира. Место выбирается так, чтобы секции шли как у редактора:
+// после секции того же вида, которая идет раньше по порядку, иначе перед такой, которая идет позже,
+// иначе после последней секции инструмента вида (например, Tools/TerrainEdit) в мире,
+// иначе после последней секции мира, иначе в конец файла.
+func (d *sessionDocument) insertSection(projectName, worldName string, ref sectionRef, section *iniSection) {
+	worldPrefix := worldSectionPrefix(projectName, worldName)
+	toolPrefix := worldPrefix + ref.Kind.Tool
+
+	before, after, lastTool, lastWorld := -1, -1, -1, -1
+	for i, existing := range d.sections {
+		if !strings.HasPrefix(existing.Header, worldPrefix) {
+			continue
+		}
+		lastWorld = i
+		if !strings.HasPrefix(existing.Header, toolPrefix) {
+			continue
+		}
+		lastTool = i
+		existingRef, ok := kindOf(existing.Header)
+		if !ok || existingRef.Kind != ref.Kind {
+			continue
+		}
+		if existingRef.less(ref) {
+			before = i
+		} else if after < 0 {
+			after = i
+		}
+	}
+
+	position := len(d.sections)
+	switch {
+	case before >= 0:
+		position = before + 1
+	case after >= 0:
+		position = after
+	case lastTool >= 0:
+		position = lastTool + 1
+	case lastWorld >= 0:
+		position = lastWorld + 1
+	}
+
+	d.sections = append(d.sections, nil)
+	copy(d.sections[position+1:], d.sections[position:])
+	d.sections[position] = section
+}
+
+// applyExtraSlotMode сбрасывает или выключает секцию слота, которого нет в пресете.
+// При сбросе значения берутся из таблицы по умолчанию профиля. Возвращает true, только если
+// ключи или значения секции действительно изменились: уже сброшенный слот изменением не считается.
+func (s *iniSection) applyExtraSlotMode(mode string, keys KeySettings) bool {
+	before, previous := slices.Clone(s.Keys), maps.Clone(s.Values)
+	switch mode {
+	case ExtraSlotsReset:
+		resetKeys, values, _ := keys.normalize(materialPairKind, s, PresetBlock{}, true)
+		s.setKeys(resetKeys, values)
+	case ExtraSlotsDisable:
+		s.set("PresetEnabled", materialPairKind.spec("PresetEnabled").Default)
+	default:
+		return false
+	}
+	return !slices.Equal(before, s.Keys) || !maps.Equal(previous, s.Values)
+}
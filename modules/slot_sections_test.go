@@ -0,0 +1,31 @@
+package modules
+
+import "testing"
+
+func TestApplyExtraSlotModeReportsOnlyRealChanges(t *testing.T) {
+	for _, mode := range []string{ExtraSlotsReset, ExtraSlotsDisable} {
+		t.Run(mode, func(t *testing.T) {
+			section := &iniSection{
+				Header: "[Worlds/test.w2w/Tools/TerrainEdit/MaterialPairSlot5]",
+				Keys:   []string{"PresetEnabled", "Probability"},
+				Values: map[string]string{"PresetEnabled": "true", "Probability": "40"},
+			}
+			keys := defaultKeySettings()
+
+			if !section.applyExtraSlotMode(mode, keys) {
+				t.Fatal("первое применение не изменило секцию")
+			}
+			if got := section.Values["PresetEnabled"]; got != "false" {
+				t.Errorf("PresetEnabled = %q, ожидалось false", got)
+			}
+			if section.applyExtraSlotMode(mode, keys) {
+				t.Errorf("повторное применение к той же секции считается изменением: %v", section.Values)
+			}
+		})
+	}
+
+	section := &iniSection{Keys: []string{"PresetEnabled"}, Values: map[string]string{"PresetEnabled": "true"}}
+	if section.applyExtraSlotMode(ExtraSlotsKeep, defaultKeySettings()) || section.Values["PresetEnabled"] != "true" {
+		t.Error("режим keep изменил секцию")
+	}
+}